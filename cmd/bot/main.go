@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/sdibella/kalshi-btc15m/internal/config"
 	"github.com/sdibella/kalshi-btc15m/internal/journal"
@@ -44,22 +45,43 @@ func main() {
 	slog.Info("kalshi btc15m bot starting",
 		"env", cfg.KalshiEnv,
 		"dryRun", cfg.DryRun,
+		"series", cfg.Series,
 	)
 
+	// Request signer shared by REST and WS; SIGHUP swaps in a new key
+	initialSigner, err := kalshi.NewSigner(cfg)
+	if err != nil {
+		slog.Error("loading kalshi key", "err", err)
+		os.Exit(1)
+	}
+	signer := kalshi.NewRotatingSigner(initialSigner)
+	slog.Info("kalshi signer ready", "source", cfg.KalshiKeySource, "keyID", signer.KeyID())
+
 	// Init Kalshi REST client
-	client, err := kalshi.NewClient(cfg)
+	client, err := kalshi.NewClient(cfg, signer)
 	if err != nil {
 		slog.Error("kalshi client init failed", "err", err)
 		os.Exit(1)
 	}
 
 	// Init Kalshi WebSocket client for orderbook streaming
-	wsClient, err := kalshi.NewWSClient(cfg)
+	wsClient, err := kalshi.NewWSClient(cfg, signer)
 	if err != nil {
 		slog.Error("kalshi ws client init failed", "err", err)
 		os.Exit(1)
 	}
 
+	if cfg.WSRecordDir != "" {
+		rec, err := kalshi.NewRecorder(cfg.WSRecordDir, time.Hour)
+		if err != nil {
+			slog.Error("ws recorder init failed", "err", err)
+			os.Exit(1)
+		}
+		defer rec.Close()
+		wsClient.SetRecorder(rec)
+		slog.Info("recording ws frames", "dir", cfg.WSRecordDir)
+	}
+
 	// Context with graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -70,6 +92,14 @@ func main() {
 	// Start dashboard subprocess
 	dashboardCmd := startDashboard()
 
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		for range hupCh {
+			rotateSigner(signer)
+		}
+	}()
+
 	go func() {
 		sig := <-sigCh
 		slog.Info("received signal, shutting down", "signal", sig)
@@ -95,13 +125,18 @@ func main() {
 	slog.Info("authenticated", "balance", fmt.Sprintf("$%.2f", float64(bal.Balance)/100.0))
 
 	// Load Bayesian posterior from file (or use default Beta(83, 3))
-	posteriorPath := "posterior.json"
-	if err := strategy.BayesianWinRate.LoadFromFile(posteriorPath); err != nil {
+	if err := strategy.BayesianWinRate.LoadFromFile(cfg.PosteriorPath); err != nil {
 		slog.Error("failed to load Bayesian posterior", "err", err)
 		// Continue with default prior
 	}
-	slog.Info("Bayesian posterior loaded (monitoring only, Kelly uses fixed 0.92)",
+	sizing := "fixed 0.92"
+	if cfg.KellyPosterior {
+		sizing = fmt.Sprintf("posterior q%.2f per bucket", cfg.KellyQuantile)
+	}
+	slog.Info("Bayesian posterior loaded",
 		"median", fmt.Sprintf("%.1f%%", strategy.BayesianWinRate.Median()*100),
+		"kellyQuantile", fmt.Sprintf("%.1f%%", strategy.BayesianWinRate.Quantile(cfg.KellyQuantile)*100),
+		"kellySizing", sizing,
 	)
 
 	// Init journal
@@ -117,7 +152,11 @@ func main() {
 	slog.Info("journal opened", "path", cfg.JournalPath)
 
 	// Start strategy engine
-	engine := strategy.NewEngine(client, wsClient, cfg, j)
+	engine, err := strategy.NewEngine(strategy.LiveDeps(client, wsClient, j), cfg)
+	if err != nil {
+		slog.Error("engine init failed", "err", err)
+		os.Exit(1)
+	}
 	if err := engine.Run(ctx); err != nil && ctx.Err() == nil {
 		slog.Error("engine error", "err", err)
 		os.Exit(1)
@@ -126,6 +165,23 @@ func main() {
 	slog.Info("bot stopped")
 }
 
+// rotateSigner reloads .env and switches signer to the configured key. The
+// old key stays active if the new one can't be loaded.
+func rotateSigner(signer *kalshi.RotatingSigner) {
+	cfg, err := config.Reload()
+	if err != nil {
+		slog.Error("key rotation: config reload failed", "err", err)
+		return
+	}
+	next, err := kalshi.NewSigner(cfg)
+	if err != nil {
+		slog.Error("key rotation: loading key failed", "err", err)
+		return
+	}
+	prev := signer.KeyID()
+	signer.Rotate(next)
+	slog.Info("kalshi signer rotated", "source", cfg.KalshiKeySource, "from", prev, "to", next.KeyID())
+}
 
 func startDashboard() *exec.Cmd {
 	// Find dashboard binary in same directory as this executable
@@ -0,0 +1,217 @@
+// Command fetch-history downloads settled markets of a series for a date
+// range — market metadata, candlesticks and the public trade tape — into one
+// JSONL file per market. Output is JSONL only; there is no Parquet writer.
+// Completed markets are skipped on re-run, so an interrupted download
+// resumes where it left off.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+func main() {
+	series := flag.String("series", "KXBTC15M", "series ticker to download")
+	from := flag.String("from", "", "first close date, YYYY-MM-DD (UTC, required)")
+	to := flag.String("to", "", "last close date, YYYY-MM-DD (UTC, inclusive; default: same as -from)")
+	outDir := flag.String("out", "./history", "output directory")
+	period := flag.Int("period", 1, "candlestick period in minutes (1, 60 or 1440)")
+	debug := flag.Bool("debug", false, "enable debug logging")
+	flag.Parse()
+
+	logLevel := slog.LevelInfo
+	if *debug {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+
+	start, end, err := parseRange(*from, *to)
+	if err != nil {
+		slog.Error("bad date range", "err", err)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config error", "err", err)
+		os.Exit(1)
+	}
+
+	signer, err := kalshi.NewSigner(cfg)
+	if err != nil {
+		slog.Error("loading kalshi key", "err", err)
+		os.Exit(1)
+	}
+
+	client, err := kalshi.NewClient(cfg, signer)
+	if err != nil {
+		slog.Error("kalshi client init failed", "err", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		slog.Error("creating output dir", "err", err)
+		os.Exit(1)
+	}
+
+	params := url.Values{
+		"series_ticker": {*series},
+		"status":        {"settled"},
+		"min_close_ts":  {strconv.FormatInt(start.Unix(), 10)},
+		"max_close_ts":  {strconv.FormatInt(end.Unix(), 10)},
+	}
+
+	done, skipped, failed := 0, 0, 0
+	for markets, err := range client.MarketsIter(ctx, params) {
+		if err != nil {
+			slog.Error("market listing failed", "err", err)
+			os.Exit(1)
+		}
+		for _, m := range markets {
+			fetched, err := fetchIfMissing(ctx, client, *series, m, *period, *outDir)
+			if err != nil {
+				if ctx.Err() != nil {
+					slog.Info("interrupted — re-run to resume", "done", done, "skipped", skipped)
+					os.Exit(1)
+				}
+				slog.Warn("market download failed", "ticker", m.Ticker, "err", err)
+				failed++
+				continue
+			}
+			if !fetched {
+				skipped++
+				continue
+			}
+			done++
+			slog.Info("market downloaded", "ticker", m.Ticker)
+		}
+	}
+
+	slog.Info("history fetch complete", "downloaded", done, "skipped", skipped, "failed", failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseRange turns the -from/-to dates into a [start, end) close-time range.
+func parseRange(from, to string) (time.Time, time.Time, error) {
+	if from == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("-from is required")
+	}
+	if to == "" {
+		to = from
+	}
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing -from: %w", err)
+	}
+	last, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing -to: %w", err)
+	}
+	end := last.AddDate(0, 0, 1)
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("-to is before -from")
+	}
+	return start, end, nil
+}
+
+// record is one line of a market's history file.
+type record struct {
+	Type        string              `json:"type"` // "market", "candle" or "trade"
+	Market      *kalshi.Market      `json:"market,omitempty"`
+	Candlestick *kalshi.Candlestick `json:"candle,omitempty"`
+	Trade       *kalshi.Trade       `json:"trade,omitempty"`
+}
+
+// fetchIfMissing downloads m into outDir unless a complete file for it is
+// already there, reporting whether it fetched.
+func fetchIfMissing(ctx context.Context, client *kalshi.Client, series string, m kalshi.Market, period int, outDir string) (bool, error) {
+	path := filepath.Join(outDir, m.Ticker+".jsonl")
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+	return true, fetchMarket(ctx, client, series, m, period, path)
+}
+
+// fetchMarket writes a market's history to path via a .partial file that is
+// renamed only once everything has been written, so a file at path is
+// always complete.
+func fetchMarket(ctx context.Context, client *kalshi.Client, series string, m kalshi.Market, period int, path string) error {
+	openTime, err := time.Parse(time.RFC3339, m.OpenTime)
+	if err != nil {
+		return fmt.Errorf("parsing open time: %w", err)
+	}
+	closeTime, err := m.CloseTimeParsed()
+	if err != nil {
+		return fmt.Errorf("parsing close time: %w", err)
+	}
+
+	tmp := path + ".partial"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp) // no-op after a successful rename
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	if err := enc.Encode(record{Type: "market", Market: &m}); err != nil {
+		f.Close()
+		return err
+	}
+
+	for candles, err := range client.CandlesticksIter(ctx, series, m.Ticker, openTime, closeTime, period) {
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("candlesticks: %w", err)
+		}
+		for i := range candles {
+			if err := enc.Encode(record{Type: "candle", Candlestick: &candles[i]}); err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+
+	params := url.Values{"ticker": {m.Ticker}}
+	for trades, err := range client.TradesIter(ctx, params) {
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("trades: %w", err)
+		}
+		for i := range trades {
+			if err := enc.Encode(record{Type: "trade", Trade: &trades[i]}); err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+func TestParseRange(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	tests := []struct {
+		from, to  string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{"2026-03-01", "", day("2026-03-01"), day("2026-03-02"), false},
+		{"2026-03-01", "2026-03-03", day("2026-03-01"), day("2026-03-04"), false},
+		{"2026-03-01", "2026-03-01", day("2026-03-01"), day("2026-03-02"), false},
+		{"", "2026-03-01", time.Time{}, time.Time{}, true},
+		{"2026-03-02", "2026-03-01", time.Time{}, time.Time{}, true},
+		{"03/01/2026", "", time.Time{}, time.Time{}, true},
+		{"2026-03-01", "tomorrow", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		start, end, err := parseRange(tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRange(%q, %q) err = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			continue
+		}
+		if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+			t.Errorf("parseRange(%q, %q) = %v, %v, want %v, %v", tt.from, tt.to, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+// historyServer serves one candle and one trade per market; with failTrades
+// the trade tape returns a server error instead.
+func historyServer(t *testing.T, failTrades bool) (*kalshi.Client, *int) {
+	t.Helper()
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/candlesticks"):
+			json.NewEncoder(w).Encode(map[string]any{"candlesticks": []kalshi.Candlestick{{EndPeriodTs: 1}}})
+		case strings.HasSuffix(r.URL.Path, "/markets/trades"):
+			if failTrades {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":{"code":"bad_request","message":"no"}}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"trades": []kalshi.Trade{{TradeID: "t1"}}})
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	client, err := kalshi.NewClient(&config.Config{KalshiBaseURL: srv.URL + "/trade-api/v2"}, kalshi.NewKeySigner("test", key))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client, &requests
+}
+
+var testMarket = kalshi.Market{
+	Ticker:    "KXBTC15M-T",
+	OpenTime:  "2026-03-01T12:00:00Z",
+	CloseTime: "2026-03-01T12:15:00Z",
+}
+
+func TestFetchIfMissingReplacesPartial(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, testMarket.Ticker+".jsonl")
+	if err := os.WriteFile(path+".partial", []byte("left by an interrupted run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := historyServer(t, false)
+	fetched, err := fetchIfMissing(context.Background(), client, "KXBTC15M", testMarket, 1, dir)
+	if err != nil || !fetched {
+		t.Fatalf("fetchIfMissing = %v, %v, want true, nil", fetched, err)
+	}
+	if _, err := os.Stat(path + ".partial"); !os.IsNotExist(err) {
+		t.Errorf(".partial still present: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var types []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("bad line %q: %v", sc.Text(), err)
+		}
+		types = append(types, rec.Type)
+	}
+	if strings.Join(types, ",") != "market,candle,trade" {
+		t.Errorf("records = %v, want market, candle, trade", types)
+	}
+}
+
+func TestFetchIfMissingSkipsCompleteFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, testMarket.Ticker+".jsonl"), []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, requests := historyServer(t, false)
+	fetched, err := fetchIfMissing(context.Background(), client, "KXBTC15M", testMarket, 1, dir)
+	if err != nil || fetched {
+		t.Fatalf("fetchIfMissing = %v, %v, want false, nil", fetched, err)
+	}
+	if *requests != 0 {
+		t.Errorf("%d requests for a complete market, want 0", *requests)
+	}
+}
+
+func TestFetchIfMissingFailureLeavesNoFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, testMarket.Ticker+".jsonl")
+
+	client, _ := historyServer(t, true)
+	if _, err := fetchIfMissing(context.Background(), client, "KXBTC15M", testMarket, 1, dir); err == nil {
+		t.Fatal("expected an error when the trade tape fails")
+	}
+	for _, p := range []string{path, path + ".partial"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s exists after a failed download", filepath.Base(p))
+		}
+	}
+
+	// The next run starts over and completes
+	client, _ = historyServer(t, false)
+	if fetched, err := fetchIfMissing(context.Background(), client, "KXBTC15M", testMarket, 1, dir); err != nil || !fetched {
+		t.Fatalf("retry = %v, %v, want true, nil", fetched, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("no file after retry: %v", err)
+	}
+}
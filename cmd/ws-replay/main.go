@@ -0,0 +1,104 @@
+// Command ws-replay rebuilds the bot's view of the Kalshi WebSocket feed
+// from a WS_RECORD_DIR recording and prints the orderbooks as they stood at
+// a chosen instant.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+func main() {
+	dir := flag.String("dir", "./ws-recordings", "recording directory")
+	at := flag.String("at", "", "replay up to this instant, RFC3339 (default: end of recording)")
+	ticker := flag.String("ticker", "", "print only this market's book")
+	speed := flag.Float64("speed", 0, "playback speed: 1 = real time, 0 = as fast as possible")
+	debug := flag.Bool("debug", false, "enable debug logging")
+	flag.Parse()
+
+	logLevel := slog.LevelInfo
+	if *debug {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+
+	r := &kalshi.Replayer{Speed: *speed}
+	if *at != "" {
+		t, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			slog.Error("bad -at", "err", err)
+			os.Exit(2)
+		}
+		r.Until = t
+	}
+
+	paths, err := kalshi.RecordingFiles(*dir)
+	if err != nil || len(paths) == 0 {
+		slog.Error("no recordings found", "dir", *dir, "err", err)
+		os.Exit(1)
+	}
+
+	// Replay needs no credentials; only the configured series matter
+	cfg := &config.Config{Series: []string{"KXBTC15M"}}
+	if loaded, err := config.Load(); err == nil {
+		cfg = loaded
+	}
+	ws, err := kalshi.NewWSClient(cfg, nil)
+	if err != nil {
+		slog.Error("ws client init failed", "err", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if err := r.Replay(ctx, ws, paths); err != nil {
+		slog.Error("replay failed", "err", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("replayed to %s\n", r.Now().UTC().Format(time.RFC3339Nano))
+	tickers := []string{*ticker}
+	if *ticker == "" {
+		tickers = tickers[:0]
+		for t := range ws.Health().BookAge {
+			tickers = append(tickers, t)
+		}
+		slices.Sort(tickers)
+	}
+	for _, t := range tickers {
+		printBook(t, ws.GetOrderbook(t))
+	}
+}
+
+func printBook(ticker string, ob *kalshi.OrderbookState) {
+	if ob == nil {
+		fmt.Printf("\n%s: no valid book\n", ticker)
+		return
+	}
+	fmt.Printf("\n%s  yes bid %d / ask %d  (updated %s)\n",
+		ticker, ob.BestYesBid(), ob.BestYesAsk(), ob.LastUpdate.UTC().Format(time.RFC3339Nano))
+	fmt.Println("  YES bids       NO bids")
+	yesBids := slices.Collect(ob.Bids("yes"))
+	noBids := slices.Collect(ob.Bids("no"))
+	for i := 0; i < max(len(yesBids), len(noBids)); i++ {
+		var yes, no string
+		if i < len(yesBids) {
+			yes = fmt.Sprintf("%3dc x %-6d", yesBids[i].Price, yesBids[i].Quantity)
+		}
+		if i < len(noBids) {
+			no = fmt.Sprintf("%3dc x %-6d", noBids[i].Price, noBids[i].Quantity)
+		}
+		fmt.Printf("  %-14s %s\n", yes, no)
+	}
+}
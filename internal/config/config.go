@@ -3,7 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,9 +15,32 @@ type Config struct {
 	KalshiAPIKeyID    string
 	KalshiPrivKeyPath string
 	KalshiEnv         string // "prod" or "demo"
+	KalshiRateTier    string // API access tier: "basic", "advanced", "premier" or "prime"
 	DryRun            bool
 	JournalPath       string
 
+	// Request signing: KalshiKeySource selects "file" (KalshiPrivKeyPath),
+	// "env" (PEM or base64 PEM in the KalshiPrivKeyEnv variable),
+	// "encrypted" (passphrase-protected PEM at KalshiPrivKeyPath) or
+	// "socket" (external signer listening on KalshiSignerSocket).
+	KalshiKeySource         string
+	KalshiPrivKeyEnv        string
+	KalshiPrivKeyPassphrase string
+	KalshiSignerSocket      string
+
+	// Endpoint overrides, e.g. for a local fake exchange. Empty uses the
+	// KalshiEnv defaults.
+	KalshiBaseURL string
+	KalshiWSURL   string
+
+	// Series traded by the engine, e.g. ["KXBTC15M", "KXETH15M"]
+	Series []string
+
+	// Entry strategy by name (see strategy.NewStrategy), with per-series
+	// overrides, e.g. {"KXETH15M": "55c-4min"}
+	Strategy         string
+	SeriesStrategies map[string]string
+
 	// Dashboard
 	DashboardPort int
 	DashboardHost string
@@ -23,9 +49,60 @@ type Config struct {
 	// Volatility filter
 	VolDataDir   string  // path to data collector's data directory
 	VolMaxStdDev float64 // stddev threshold in dollars to block trading
+
+	// Fair value filter: when on, enter only if the model probability of the
+	// signal's side exceeds its entry price by at least FairValueMinEdge cents
+	FairValueFilter  bool
+	FairValueMinEdge float64
+
+	// Kelly sizing: when KellyPosterior is on, size with the KellyQuantile
+	// quantile of the win-rate posterior for the entry's bucket (side, entry
+	// price in PosteriorBucketCents steps and, if PosteriorVolSplit > 0,
+	// calm/volatile regime) instead of the fixed 0.92. A bucket overrides
+	// the global posterior (PosteriorPath) once it has PosteriorMinObs
+	// settled trades. Bucket counts persist in BucketPosteriorPath.
+	KellyPosterior       bool
+	KellyQuantile        float64
+	PosteriorPath        string
+	BucketPosteriorPath  string
+	PosteriorBucketCents int
+	PosteriorMinObs      int
+	PosteriorVolSplit    float64
+
+	// Depth-aware sizing: when on, taker entries walk the asks (see
+	// strategy.DepthSize), taking at most MaxSlippageCents above the best
+	// ask (negative = no cap) with one limit at the worst level taken
+	DepthSizing      bool
+	MaxSlippageCents int
+
+	// Pre-close exits: sell an open position at the bid when the book flips
+	// against it (ExitOnFlip), the index is ExitStrikeCross dollars on the
+	// losing side of the strike, or its value at the bid is ExitMaxDrawdown
+	// (a fraction of the entry price) below entry. Zero turns a rule off.
+	ExitOnFlip      bool
+	ExitStrikeCross float64
+	ExitMaxDrawdown float64
+
+	// Refuse to trade off an orderbook when neither it nor the WS
+	// connection has been heard from within this long
+	MaxBookAge time.Duration
+
+	// Raw WS recording: directory for hourly gzipped frame logs ("" = off)
+	WSRecordDir string
+}
+
+// StrategyFor returns the name of the strategy configured for a series.
+func (c *Config) StrategyFor(series string) string {
+	if name := c.SeriesStrategies[series]; name != "" {
+		return name
+	}
+	return c.Strategy
 }
 
 func (c *Config) BaseURL() string {
+	if c.KalshiBaseURL != "" {
+		return c.KalshiBaseURL
+	}
 	if c.KalshiEnv == "prod" {
 		return "https://api.elections.kalshi.com/trade-api/v2"
 	}
@@ -33,6 +110,9 @@ func (c *Config) BaseURL() string {
 }
 
 func (c *Config) WSBaseURL() string {
+	if c.KalshiWSURL != "" {
+		return c.KalshiWSURL
+	}
 	if c.KalshiEnv == "prod" {
 		return "wss://api.elections.kalshi.com/trade-api/ws/v2"
 	}
@@ -46,25 +126,98 @@ func Load() (*Config, error) {
 		KalshiAPIKeyID:    os.Getenv("KALSHI_API_KEY_ID"),
 		KalshiPrivKeyPath: getEnvDefault("KALSHI_PRIV_KEY_PATH", "./kalshi_private_key.pem"),
 		KalshiEnv:         getEnvDefault("KALSHI_ENV", "prod"),
+		KalshiRateTier:    getEnvDefault("KALSHI_RATE_TIER", "basic"),
 		DryRun:            getEnvBool("DRY_RUN", true),
 		JournalPath:       getEnvDefault("JOURNAL_PATH", "./journal.jsonl"),
+		Series:            getEnvList("SERIES", []string{"KXBTC15M"}),
+		Strategy:          getEnvDefault("STRATEGY", "80c"),
 		DashboardPort:     getEnvInt("DASHBOARD_PORT", 8080),
 		DashboardHost:     getEnvDefault("DASHBOARD_HOST", "localhost"),
 		JournalDir:        getEnvDefault("DASHBOARD_JOURNAL_DIR", "."),
 		VolDataDir:        getEnvDefault("VOL_DATA_DIR", "/home/stefan/KalshiBTC15min-data/data"),
 		VolMaxStdDev:      getEnvFloat("VOL_MAX_STDDEV", 200.0),
+		MaxBookAge:        getEnvDuration("MAX_BOOK_AGE", 30*time.Second),
+		FairValueFilter:   getEnvBool("FAIR_VALUE_FILTER", false),
+		FairValueMinEdge:  getEnvFloat("FAIR_VALUE_MIN_EDGE", 2),
+		WSRecordDir:       os.Getenv("WS_RECORD_DIR"),
+
+		KellyPosterior:       getEnvBool("KELLY_POSTERIOR", false),
+		KellyQuantile:        getEnvFloat("KELLY_QUANTILE", 0.05),
+		PosteriorPath:        getEnvDefault("POSTERIOR_PATH", "posterior.json"),
+		BucketPosteriorPath:  getEnvDefault("BUCKET_POSTERIOR_PATH", "posterior_buckets.json"),
+		PosteriorBucketCents: getEnvInt("POSTERIOR_BUCKET_CENTS", 5),
+		PosteriorMinObs:      getEnvInt("POSTERIOR_MIN_OBS", 30),
+		PosteriorVolSplit:    getEnvFloat("POSTERIOR_VOL_SPLIT", 0),
+
+		DepthSizing:      getEnvBool("DEPTH_SIZING", false),
+		MaxSlippageCents: getEnvInt("MAX_SLIPPAGE_CENTS", 2),
+
+		ExitOnFlip:      getEnvBool("EXIT_ON_FLIP", false),
+		ExitStrikeCross: getEnvFloat("EXIT_STRIKE_CROSS", 0),
+		ExitMaxDrawdown: getEnvFloat("EXIT_MAX_DRAWDOWN", 0),
+
+		KalshiKeySource:         getEnvDefault("KALSHI_KEY_SOURCE", "file"),
+		KalshiPrivKeyEnv:        getEnvDefault("KALSHI_PRIV_KEY_ENV", "KALSHI_PRIV_KEY"),
+		KalshiPrivKeyPassphrase: os.Getenv("KALSHI_PRIV_KEY_PASSPHRASE"),
+		KalshiSignerSocket:      os.Getenv("KALSHI_SIGNER_SOCKET"),
+
+		KalshiBaseURL: os.Getenv("KALSHI_BASE_URL"),
+		KalshiWSURL:   os.Getenv("KALSHI_WS_URL"),
 	}
 
 	if cfg.KalshiAPIKeyID == "" {
 		return nil, fmt.Errorf("KALSHI_API_KEY_ID is required")
 	}
+	if len(cfg.Series) == 0 {
+		return nil, fmt.Errorf("SERIES must list at least one series ticker")
+	}
+	strategies, err := getEnvMap("SERIES_STRATEGIES")
+	if err != nil {
+		return nil, err
+	}
+	for series := range strategies {
+		if !slices.Contains(cfg.Series, series) {
+			return nil, fmt.Errorf("SERIES_STRATEGIES names %s, which is not in SERIES", series)
+		}
+	}
+	cfg.SeriesStrategies = strategies
 	if cfg.KalshiEnv != "prod" && cfg.KalshiEnv != "demo" {
 		return nil, fmt.Errorf("KALSHI_ENV must be 'prod' or 'demo', got %q", cfg.KalshiEnv)
 	}
+	if cfg.KellyQuantile <= 0 || cfg.KellyQuantile >= 1 {
+		return nil, fmt.Errorf("KELLY_QUANTILE must be between 0 and 1, got %v", cfg.KellyQuantile)
+	}
+	if cfg.PosteriorBucketCents <= 0 {
+		return nil, fmt.Errorf("POSTERIOR_BUCKET_CENTS must be positive, got %d", cfg.PosteriorBucketCents)
+	}
+	if cfg.ExitMaxDrawdown < 0 || cfg.ExitMaxDrawdown > 1 {
+		return nil, fmt.Errorf("EXIT_MAX_DRAWDOWN must be between 0 and 1, got %v", cfg.ExitMaxDrawdown)
+	}
+	switch cfg.KalshiKeySource {
+	case "file", "env", "encrypted":
+	case "socket":
+		if cfg.KalshiSignerSocket == "" {
+			return nil, fmt.Errorf("KALSHI_SIGNER_SOCKET is required when KALSHI_KEY_SOURCE=socket")
+		}
+	default:
+		return nil, fmt.Errorf("KALSHI_KEY_SOURCE must be file, env, encrypted or socket, got %q", cfg.KalshiKeySource)
+	}
+	switch cfg.KalshiRateTier {
+	case "basic", "advanced", "premier", "prime":
+	default:
+		return nil, fmt.Errorf("KALSHI_RATE_TIER must be basic, advanced, premier or prime, got %q", cfg.KalshiRateTier)
+	}
 
 	return cfg, nil
 }
 
+// Reload re-reads .env, overriding values already in the environment, so
+// edits made while the bot is running take effect.
+func Reload() (*Config, error) {
+	_ = godotenv.Overload()
+	return Load()
+}
+
 func getEnvDefault(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -96,6 +249,18 @@ func getEnvFloat(key string, def float64) float64 {
 	return f
 }
 
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
 func getEnvInt(key string, def int) int {
 	v := os.Getenv(key)
 	if v == "" {
@@ -107,3 +272,34 @@ func getEnvInt(key string, def int) int {
 	}
 	return n
 }
+
+func getEnvList(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, strings.ToUpper(item))
+		}
+	}
+	return out
+}
+
+// getEnvMap parses "SERIES=value,..." pairs, uppercasing the series.
+func getEnvMap(key string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(item, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("%s: expected SERIES=value, got %q", key, item)
+		}
+		out[strings.ToUpper(k)] = v
+	}
+	return out, nil
+}
@@ -87,9 +87,13 @@ func (a *Analyzer) processTrade(t journal.Trade) {
 		a.trades[ticker] = agg
 	}
 
+	// Exits reduce the position but not its entry price
+	agg.fees += t.FeeCents
+	if t.Action == "sell" {
+		return
+	}
 	agg.quantity += t.Quantity
 	agg.totalCost += t.Quantity * t.Price
-	agg.fees += t.FeeCents
 }
 
 func (a *Analyzer) processSettlement(s journal.Settlement) {
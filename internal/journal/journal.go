@@ -78,6 +78,14 @@ type Trade struct {
 	Filled     int    `json:"filled"`
 	DryRun     bool   `json:"dry_run"`
 	LimitPrice int    `json:"limit_price"`
+
+	// Execution quality: the average price per contract the engine
+	// expected from the book when it placed the order, and what it paid.
+	ExpectedAvgPrice float64 `json:"expected_avg_price,omitempty"`
+	RealizedAvgPrice float64 `json:"realized_avg_price,omitempty"`
+
+	// ExitReason is set on sells that close a position before settlement.
+	ExitReason string `json:"exit_reason,omitempty"`
 }
 
 func NewTrade(ticker, side, action string, price, quantity, feeCents int, orderID string, filled int, dryRun bool, limitPrice int) Trade {
@@ -97,6 +105,19 @@ func NewTrade(ticker, side, action string, price, quantity, feeCents int, orderI
 	}
 }
 
+// WithExecution records the expected and realized average fill prices.
+func (t Trade) WithExecution(expected, realized float64) Trade {
+	t.ExpectedAvgPrice = expected
+	t.RealizedAvgPrice = realized
+	return t
+}
+
+// WithExitReason records why a sell closed the position early.
+func (t Trade) WithExitReason(reason string) Trade {
+	t.ExitReason = reason
+	return t
+}
+
 type Settlement struct {
 	Type            string    `json:"type"`
 	Time            string    `json:"time"`
@@ -111,6 +132,22 @@ type Settlement struct {
 	Contracts       int       `json:"contracts"`
 	SettlementTicks []float64 `json:"settlement_ticks"`
 	DryRun          bool      `json:"dry_run"`
+
+	// PnLSource is "exchange" when PnLCents comes from the portfolio
+	// settlement record, or "local" when it was computed by the bot.
+	PnLSource     string `json:"pnl_source"`
+	LocalPnLCents int    `json:"local_pnl_cents"`
+
+	ExchangeRevenueCents int  `json:"exchange_revenue_cents,omitempty"`
+	ExchangeFeeCents     int  `json:"exchange_fee_cents,omitempty"`
+	ExchangeYesCount     int  `json:"exchange_yes_count,omitempty"`
+	ExchangeNoCount      int  `json:"exchange_no_count,omitempty"`
+	PnLMismatch          bool `json:"pnl_mismatch,omitempty"` // local and exchange P&L disagree
+
+	// Contracts sold before settlement, out of Contracts, and what they
+	// brought in before fees. FeeCents includes the exit fees.
+	ExitedContracts   int `json:"exited_contracts,omitempty"`
+	ExitProceedsCents int `json:"exit_proceeds_cents,omitempty"`
 }
 
 func NewSettlement(ticker string, strike, avgBRTI float64, won bool, pnl, fees int, side string, entryPrice, contracts int, ticks []float64, dryRun bool) Settlement {
@@ -128,5 +165,50 @@ func NewSettlement(ticker string, strike, avgBRTI float64, won bool, pnl, fees i
 		Contracts:       contracts,
 		SettlementTicks: ticks,
 		DryRun:          dryRun,
+		PnLSource:       "local",
+		LocalPnLCents:   pnl,
+	}
+}
+
+// WithExchange records the exchange's settlement figures, making them the
+// authoritative P&L and fees. The locally computed P&L is kept alongside and
+// PnLMismatch is set if the two differ.
+func (s Settlement) WithExchange(revenue, fees, yesCount, noCount, pnl int) Settlement {
+	s.PnLSource = "exchange"
+	s.ExchangeRevenueCents = revenue
+	s.ExchangeFeeCents = fees
+	s.ExchangeYesCount = yesCount
+	s.ExchangeNoCount = noCount
+	s.PnLCents = pnl
+	s.FeeCents = fees
+	s.Won = pnl > 0
+	s.PnLMismatch = pnl != s.LocalPnLCents
+	return s
+}
+
+// WithExit records contracts sold before settlement for proceeds cents.
+func (s Settlement) WithExit(contracts, proceeds int) Settlement {
+	s.ExitedContracts = contracts
+	s.ExitProceedsCents = proceeds
+	return s
+}
+
+// ExchangeStatus records a transition of the exchange between trading and
+// halted, as observed by the engine.
+type ExchangeStatus struct {
+	Type          string `json:"type"`
+	Time          string `json:"time"`
+	TradingActive bool   `json:"trading_active"`
+	Reason        string `json:"reason"`
+	ResumeTime    string `json:"resume_time,omitempty"`
+}
+
+func NewExchangeStatus(tradingActive bool, reason, resumeTime string) ExchangeStatus {
+	return ExchangeStatus{
+		Type:          "exchange_status",
+		Time:          time.Now().UTC().Format(time.RFC3339Nano),
+		TradingActive: tradingActive,
+		Reason:        reason,
+		ResumeTime:    resumeTime,
 	}
 }
@@ -12,8 +12,6 @@ import (
 	"os"
 	"strconv"
 	"time"
-
-	"github.com/sdibella/kalshi-btc15m/internal/config"
 )
 
 func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
@@ -22,13 +20,25 @@ func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
 		return nil, fmt.Errorf("reading private key: %w", err)
 	}
 
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParsePrivateKey decodes an unencrypted PEM-encoded RSA key.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(data)
 	if block == nil {
-		return nil, fmt.Errorf("no PEM block found in %s", path)
+		return nil, fmt.Errorf("no PEM block found")
 	}
+	return parseKeyDER(block.Bytes)
+}
 
+func parseKeyDER(der []byte) (*rsa.PrivateKey, error) {
 	// Try PKCS8 first (standard format)
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	key, err := x509.ParsePKCS8PrivateKey(der)
 	if err == nil {
 		rsaKey, ok := key.(*rsa.PrivateKey)
 		if !ok {
@@ -38,7 +48,7 @@ func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
 	}
 
 	// Fallback to PKCS1 (older RSA-specific format)
-	rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	rsaKey, err := x509.ParsePKCS1PrivateKey(der)
 	if err != nil {
 		return nil, fmt.Errorf("parsing private key (tried PKCS8 and PKCS1): %w", err)
 	}
@@ -47,7 +57,10 @@ func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
 }
 
 func Sign(privateKey *rsa.PrivateKey, timestampMS string, method string, path string) (string, error) {
-	message := timestampMS + method + path
+	return signPSS(privateKey, timestampMS+method+path)
+}
+
+func signPSS(privateKey *rsa.PrivateKey, message string) (string, error) {
 	hash := sha256.Sum256([]byte(message))
 
 	sig, err := rsa.SignPSS(rand.Reader, privateKey, crypto.SHA256, hash[:], &rsa.PSSOptions{
@@ -60,16 +73,23 @@ func Sign(privateKey *rsa.PrivateKey, timestampMS string, method string, path st
 	return base64.StdEncoding.EncodeToString(sig), nil
 }
 
-func AuthHeaders(cfg *config.Config, privateKey *rsa.PrivateKey, method string, path string) (map[string]string, error) {
+// AuthHeaders signs method+path with the signer's current key and returns
+// the three Kalshi auth headers. The key ID and signature always come from
+// the same key, even if a RotatingSigner is rotated concurrently.
+func AuthHeaders(signer Signer, method string, path string) (map[string]string, error) {
+	if r, ok := signer.(*RotatingSigner); ok {
+		signer = r.Current()
+	}
+
 	ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
 
-	sig, err := Sign(privateKey, ts, method, path)
+	sig, err := signer.Sign(ts + method + path)
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]string{
-		"KALSHI-ACCESS-KEY":       cfg.KalshiAPIKeyID,
+		"KALSHI-ACCESS-KEY":       signer.KeyID(),
 		"KALSHI-ACCESS-TIMESTAMP": ts,
 		"KALSHI-ACCESS-SIGNATURE": sig,
 	}, nil
@@ -1,17 +1,16 @@
 package kalshi
 
 import (
+	"bytes"
 	"context"
-	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log/slog"
 	"net/http"
 	"net/url"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/sdibella/kalshi-btc15m/internal/config"
@@ -19,30 +18,43 @@ import (
 
 type Client struct {
 	cfg            *config.Config
-	privKey        *rsa.PrivateKey
+	signer         Signer
 	http           *http.Client
 	baseURL        string
 	basePathPrefix string // e.g. "/trade-api/v2"
+
+	readLimiter  *rateLimiter
+	writeLimiter *rateLimiter
+	retry        retryPolicy
 }
 
-func NewClient(cfg *config.Config) (*Client, error) {
-	key, err := LoadPrivateKey(cfg.KalshiPrivKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("loading kalshi key: %w", err)
-	}
+// NewClient builds a REST client that signs requests with signer. Pass the
+// same signer to NewWSClient so a key rotation applies to both.
+func NewClient(cfg *config.Config, signer Signer) (*Client, error) {
+	return newClient(cfg, signer, cfg.BaseURL())
+}
 
+func newClient(cfg *config.Config, signer Signer, baseURL string) (*Client, error) {
 	// Extract the URL path prefix (e.g. "/trade-api/v2") for signing
-	parsed, err := url.Parse(cfg.BaseURL())
+	parsed, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("parsing base URL: %w", err)
 	}
 
+	tier, ok := rateTiers[cfg.KalshiRateTier]
+	if !ok {
+		tier = rateTiers["basic"]
+	}
+
 	return &Client{
 		cfg:            cfg,
-		privKey:        key,
+		signer:         signer,
 		http:           &http.Client{Timeout: 10 * time.Second},
-		baseURL:        cfg.BaseURL(),
+		baseURL:        baseURL,
 		basePathPrefix: parsed.Path,
+		readLimiter:    newRateLimiter(tier[0]),
+		writeLimiter:   newRateLimiter(tier[1]),
+		retry:          defaultRetryPolicy,
 	}, nil
 }
 
@@ -55,57 +67,40 @@ func (c *Client) signPath(path string) string {
 // --- API Types ---
 
 type Market struct {
-	Ticker                 string  `json:"ticker"`
-	EventTicker            string  `json:"event_ticker"`
-	Title                  string  `json:"title"`
-	Status                 string  `json:"status"`
-	YesBid                 int     `json:"yes_bid"`
-	YesAsk                 int     `json:"yes_ask"`
-	NoBid                  int     `json:"no_bid"`
-	NoAsk                  int     `json:"no_ask"`
-	LastPrice              int     `json:"last_price"`
-	Volume                 int     `json:"volume"`
-	OpenInterest           int     `json:"open_interest"`
-	FloorStrike            float64 `json:"floor_strike"`
-	CapStrike              float64 `json:"cap_strike"`
-	CloseTime              string  `json:"close_time"`
-	OpenTime               string  `json:"open_time"`
-	ExpirationTime         string  `json:"expiration_time"`
-	ExpectedExpirationTime string  `json:"expected_expiration_time"`
-	Result                 string  `json:"result"`
-	Subtitle               string  `json:"subtitle"`
-	YesSubTitle            string  `json:"yes_sub_title"`
-	NoSubTitle             string  `json:"no_sub_title"`
-	CustomStrike           string  `json:"custom_strike"`
-	RulesPrimary           string  `json:"rules_primary"`
+	Ticker                 string          `json:"ticker"`
+	EventTicker            string          `json:"event_ticker"`
+	Title                  string          `json:"title"`
+	Status                 string          `json:"status"`
+	YesBid                 int             `json:"yes_bid"`
+	YesAsk                 int             `json:"yes_ask"`
+	NoBid                  int             `json:"no_bid"`
+	NoAsk                  int             `json:"no_ask"`
+	LastPrice              int             `json:"last_price"`
+	Volume                 int             `json:"volume"`
+	OpenInterest           int             `json:"open_interest"`
+	StrikeType             string          `json:"strike_type"`
+	FloorStrike            float64         `json:"floor_strike"`
+	CapStrike              float64         `json:"cap_strike"`
+	CloseTime              string          `json:"close_time"`
+	OpenTime               string          `json:"open_time"`
+	ExpirationTime         string          `json:"expiration_time"`
+	ExpectedExpirationTime string          `json:"expected_expiration_time"`
+	Result                 string          `json:"result"`
+	Subtitle               string          `json:"subtitle"`
+	YesSubTitle            string          `json:"yes_sub_title"`
+	NoSubTitle             string          `json:"no_sub_title"`
+	CustomStrike           json.RawMessage `json:"custom_strike"`
+	RulesPrimary           string          `json:"rules_primary"`
 }
 
+// StrikePrice returns the market's strike, or 0 if it can't be determined.
+// Use ParseStrike for the full condition and the reason a parse failed.
 func (m *Market) StrikePrice() float64 {
-	if m.CapStrike > 0 {
-		return m.CapStrike
-	}
-	if m.FloorStrike > 0 {
-		return m.FloorStrike
-	}
-
-	// For KXBTC15M markets, parse strike from rules_primary
-	// Example: "...is at least 70382.44, then..."
-	if m.RulesPrimary != "" {
-		var strike float64
-		// Look for "at least X" pattern
-		if _, err := fmt.Sscanf(m.RulesPrimary, "%*s at least %f", &strike); err == nil && strike > 0 {
-			return strike
-		}
-		// Look for "is at least X" pattern (more common)
-		re := regexp.MustCompile(`is at least ([\d.]+)`)
-		if matches := re.FindStringSubmatch(m.RulesPrimary); len(matches) > 1 {
-			if strike, err := strconv.ParseFloat(matches[1], 64); err == nil {
-				return strike
-			}
-		}
+	spec, err := ParseStrike(m)
+	if err != nil {
+		return 0
 	}
-
-	return 0
+	return spec.Strike()
 }
 
 func (m *Market) ExpirationParsed() (time.Time, error) {
@@ -157,27 +152,66 @@ type Position struct {
 }
 
 type OrderRequest struct {
-	Ticker      string `json:"ticker"`
-	Action      string `json:"action"` // "buy" or "sell"
-	Side        string `json:"side"`   // "yes" or "no"
-	Type        string `json:"type"`   // "limit" or "market"
-	Count       int    `json:"count"`
-	YesPrice    int    `json:"yes_price,omitempty"`
-	NoPrice     int    `json:"no_price,omitempty"`
-	TimeInForce string `json:"time_in_force,omitempty"` // "good_till_canceled", "immediate_or_cancel", "fill_or_kill"
+	Ticker        string `json:"ticker"`
+	ClientOrderID string `json:"client_order_id,omitempty"` // exchange rejects duplicates, making re-sends safe
+	Action        string `json:"action"`                    // "buy" or "sell"
+	Side          string `json:"side"`                      // "yes" or "no"
+	Type          string `json:"type"`                      // "limit" or "market"
+	Count         int    `json:"count"`
+	YesPrice      int    `json:"yes_price,omitempty"`
+	NoPrice       int    `json:"no_price,omitempty"`
+	TimeInForce   string `json:"time_in_force,omitempty"` // "good_till_canceled", "immediate_or_cancel", "fill_or_kill"
 }
 
 type Order struct {
 	OrderID        string `json:"order_id"`
+	ClientOrderID  string `json:"client_order_id"`
 	Ticker         string `json:"ticker"`
-	Status         string `json:"status"`
+	Status         string `json:"status"` // "resting", "canceled", "executed", "pending"
 	Action         string `json:"action"`
 	Side           string `json:"side"`
 	Type           string `json:"type"`
 	YesPrice       int    `json:"yes_price"`
 	NoPrice        int    `json:"no_price"`
+	InitialCount   int    `json:"initial_count"`
 	RemainingCount int    `json:"remaining_count"`
-	FilledCount    int    `json:"place_count"`
+	FilledCount    int    `json:"fill_count"`
+	TakerFillCost  int    `json:"taker_fill_cost"` // cents paid on taker fills
+	MakerFillCost  int    `json:"maker_fill_cost"` // cents paid on maker fills
+	TakerFees      int    `json:"taker_fees"`
+	MakerFees      int    `json:"maker_fees"`
+	CreatedTime    string `json:"created_time"`
+	LastUpdateTime string `json:"last_update_time"`
+}
+
+// IsFinal reports whether the order can no longer fill.
+func (o *Order) IsFinal() bool {
+	return o.Status == "executed" || o.Status == "canceled"
+}
+
+// LimitPrice returns the order's limit in the ordered side's terms.
+func (o *Order) LimitPrice() int {
+	if o.Side == "no" {
+		return o.NoPrice
+	}
+	return o.YesPrice
+}
+
+// AvgFillPrice returns the average price paid per filled contract, falling
+// back to the limit when the exchange didn't report fill costs.
+func (o *Order) AvgFillPrice() int {
+	if o.FilledCount == 0 {
+		return 0
+	}
+	if cost := o.TakerFillCost + o.MakerFillCost; cost > 0 {
+		return cost / o.FilledCount
+	}
+	return o.LimitPrice()
+}
+
+// FeesPaid returns total exchange fees charged on the order's fills.
+func (o *Order) FeesPaid() int {
+	return o.TakerFees + o.MakerFees
 }
 
 // --- API Methods ---
@@ -192,6 +226,8 @@ func (c *Client) GetMarket(ctx context.Context, ticker string) (*Market, error)
 	return &response.Market, nil
 }
 
+// GetMarkets returns every market matching the series and status filters,
+// following the cursor across as many pages as the exchange returns.
 func (c *Client) GetMarkets(ctx context.Context, seriesTicker string, status string) ([]Market, error) {
 	params := url.Values{}
 	if seriesTicker != "" {
@@ -200,16 +236,21 @@ func (c *Client) GetMarkets(ctx context.Context, seriesTicker string, status str
 	if status != "" {
 		params.Set("status", status)
 	}
-	params.Set("limit", "200")
+	return collect(c.MarketsIter(ctx, params))
+}
 
-	var result struct {
-		Markets []Market `json:"markets"`
-		Cursor  string   `json:"cursor"`
-	}
-	if err := c.get(ctx, "/markets", params, &result); err != nil {
-		return nil, err
-	}
-	return result.Markets, nil
+// MarketsIter yields pages of /markets for the given query parameters.
+func (c *Client) MarketsIter(ctx context.Context, params url.Values) iter.Seq2[[]Market, error] {
+	return pages(ctx, func(ctx context.Context, cursor string) ([]Market, string, error) {
+		var result struct {
+			Markets []Market `json:"markets"`
+			Cursor  string   `json:"cursor"`
+		}
+		if err := c.get(ctx, "/markets", pageParams(params, cursor), &result); err != nil {
+			return nil, "", err
+		}
+		return result.Markets, result.Cursor, nil
+	})
 }
 
 func (c *Client) GetOrderbook(ctx context.Context, ticker string, depth int) (*Orderbook, error) {
@@ -235,20 +276,27 @@ func (c *Client) GetBalance(ctx context.Context) (*Balance, error) {
 	return &result, nil
 }
 
+// GetPositions returns every market position, optionally scoped to an event.
 func (c *Client) GetPositions(ctx context.Context, eventTicker string) ([]Position, error) {
 	params := url.Values{}
 	if eventTicker != "" {
 		params.Set("event_ticker", eventTicker)
 	}
-	params.Set("limit", "200")
+	return collect(c.PositionsIter(ctx, params))
+}
 
-	var result struct {
-		Positions []Position `json:"market_positions"`
-	}
-	if err := c.get(ctx, "/portfolio/positions", params, &result); err != nil {
-		return nil, err
-	}
-	return result.Positions, nil
+// PositionsIter yields pages of /portfolio/positions.
+func (c *Client) PositionsIter(ctx context.Context, params url.Values) iter.Seq2[[]Position, error] {
+	return pages(ctx, func(ctx context.Context, cursor string) ([]Position, string, error) {
+		var result struct {
+			Positions []Position `json:"market_positions"`
+			Cursor    string     `json:"cursor"`
+		}
+		if err := c.get(ctx, "/portfolio/positions", pageParams(params, cursor), &result); err != nil {
+			return nil, "", err
+		}
+		return result.Positions, result.Cursor, nil
+	})
 }
 
 func (c *Client) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
@@ -261,10 +309,6 @@ func (c *Client) CreateOrder(ctx context.Context, req OrderRequest) (*Order, err
 	return &result.Order, nil
 }
 
-func (c *Client) CancelOrder(ctx context.Context, orderID string) error {
-	return c.delete(ctx, "/portfolio/orders/"+orderID)
-}
-
 type Fill struct {
 	FillID      string `json:"fill_id"`
 	OrderID     string `json:"order_id"`
@@ -278,40 +322,29 @@ type Fill struct {
 	CreatedTime string `json:"created_time"`
 }
 
-func (c *Client) GetFills(ctx context.Context, params url.Values) ([]Fill, string, error) {
-	var result struct {
-		Fills  []Fill `json:"fills"`
-		Cursor string `json:"cursor"`
-	}
-	if err := c.get(ctx, "/portfolio/fills", params, &result); err != nil {
-		return nil, "", err
-	}
-	return result.Fills, result.Cursor, nil
+// GetFills returns every fill matching params (e.g. ticker, order_id).
+func (c *Client) GetFills(ctx context.Context, params url.Values) ([]Fill, error) {
+	return collect(c.FillsIter(ctx, params))
+}
+
+// FillsIter yields pages of /portfolio/fills.
+func (c *Client) FillsIter(ctx context.Context, params url.Values) iter.Seq2[[]Fill, error] {
+	return pages(ctx, func(ctx context.Context, cursor string) ([]Fill, string, error) {
+		var result struct {
+			Fills  []Fill `json:"fills"`
+			Cursor string `json:"cursor"`
+		}
+		if err := c.get(ctx, "/portfolio/fills", pageParams(params, cursor), &result); err != nil {
+			return nil, "", err
+		}
+		return result.Fills, result.Cursor, nil
+	})
 }
 
 // --- HTTP helpers ---
 
 func (c *Client) get(ctx context.Context, path string, params url.Values, out interface{}) error {
-	reqURL := c.baseURL + path
-	if params != nil && len(params) > 0 {
-		reqURL += "?" + params.Encode()
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
-	if err != nil {
-		return err
-	}
-
-	headers, err := AuthHeaders(c.cfg, c.privKey, "GET", c.signPath(path))
-	if err != nil {
-		return err
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	req.Header.Set("Accept", "application/json")
-
-	return c.doRequest(req, out)
+	return c.do(ctx, "GET", path, params, nil, out)
 }
 
 func (c *Client) post(ctx context.Context, path string, body interface{}, out interface{}) error {
@@ -319,41 +352,109 @@ func (c *Client) post(ctx context.Context, path string, body interface{}, out in
 	if err != nil {
 		return err
 	}
+	return c.do(ctx, "POST", path, nil, data, out)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, strings.NewReader(string(data)))
-	if err != nil {
-		return err
+func (c *Client) delete(ctx context.Context, path string, body interface{}, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
 	}
+	return c.do(ctx, "DELETE", path, nil, data, out)
+}
 
-	headers, err := AuthHeaders(c.cfg, c.privKey, "POST", c.signPath(path))
-	if err != nil {
-		return err
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+// do sends a signed request, waiting on the read or write rate limiter and
+// retrying retryable failures with jittered backoff. GET and DELETE are
+// idempotent and are also retried on transport errors; POST is only retried
+// when the exchange rejected it outright with a 429, since any other failure
+// may have reached the matching engine.
+func (c *Client) do(ctx context.Context, method, path string, params url.Values, body []byte, out interface{}) error {
+	limiter := c.readLimiter
+	if method != "GET" {
+		limiter = c.writeLimiter
+	}
+	idempotent := method == "GET" || method == "DELETE"
+
+	var lastErr error
+	for attempt := 0; attempt < c.retry.MaxAttempts; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		req, err := c.newRequest(ctx, method, path, params, body)
+		if err != nil {
+			return err
+		}
+
+		lastErr = c.doRequest(req, out)
+		if lastErr == nil {
+			return nil
+		}
 
-	return c.doRequest(req, out)
+		var apiErr *APIError
+		var retryAfter time.Duration
+		switch {
+		case errors.As(lastErr, &apiErr):
+			if !apiErr.IsRetryable() || (!idempotent && !apiErr.IsRateLimited()) {
+				return lastErr
+			}
+			retryAfter = apiErr.RetryAfter
+		case ctx.Err() != nil:
+			return lastErr
+		case !idempotent:
+			return lastErr
+		}
+
+		if attempt == c.retry.MaxAttempts-1 {
+			break
+		}
+		delay := c.retry.backoff(attempt, retryAfter)
+		slog.Warn("kalshi request retrying",
+			"method", method,
+			"path", path,
+			"attempt", attempt+1,
+			"delay", delay,
+			"err", lastErr,
+		)
+		if err := sleepCtx(ctx, delay); err != nil {
+			return lastErr
+		}
+	}
+	return lastErr
 }
 
-func (c *Client) delete(ctx context.Context, path string) error {
-	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+path, nil)
+// newRequest builds a freshly signed request; signatures embed a timestamp,
+// so every retry must be re-signed.
+func (c *Client) newRequest(ctx context.Context, method, path string, params url.Values, body []byte) (*http.Request, error) {
+	reqURL := c.baseURL + path
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bodyReader)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Fix: use c.signPath(path) for correct DELETE signing
-	headers, err := AuthHeaders(c.cfg, c.privKey, "DELETE", c.signPath(path))
+	headers, err := AuthHeaders(c.signer, method, c.signPath(path))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
-
-	return c.doRequest(req, nil)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
 }
 
 func (c *Client) doRequest(req *http.Request, out interface{}) error {
@@ -371,8 +472,9 @@ func (c *Client) doRequest(req *http.Request, out interface{}) error {
 	}
 
 	if resp.StatusCode >= 400 {
-		slog.Error("kalshi API error", "status", resp.StatusCode, "body", string(body))
-		return fmt.Errorf("kalshi API error %d: %s", resp.StatusCode, string(body))
+		apiErr := newAPIError(resp, body)
+		slog.Error("kalshi API error", "status", resp.StatusCode, "code", apiErr.Code, "body", string(body))
+		return apiErr
 	}
 
 	if out != nil {
@@ -0,0 +1,268 @@
+package kalshi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+)
+
+// newTestClient returns a Client pointed at srv with a throwaway key and
+// near-zero backoff.
+func newTestClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	c, err := newClient(&config.Config{KalshiAPIKeyID: "test"}, NewKeySigner("test", key), srv.URL+"/trade-api/v2")
+	if err != nil {
+		t.Fatalf("newClient: %v", err)
+	}
+	c.retry = retryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	return c
+}
+
+func TestAPIErrorDecoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"insufficient_balance","message":"not enough funds"}}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv)
+	_, err := c.GetBalance(context.Background())
+
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("err = %T %v, want *APIError", err, err)
+	}
+	if apiErr.StatusCode != 400 || apiErr.Code != "insufficient_balance" || apiErr.Message != "not enough funds" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+	if IsRetryable(err) || IsRateLimited(err) {
+		t.Errorf("400 should not be retryable or rate limited")
+	}
+}
+
+func TestGetRetriesOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"balance": 1234}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv)
+	bal, err := c.GetBalance(context.Background())
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if bal.Balance != 1234 || calls.Load() != 3 {
+		t.Errorf("balance=%d calls=%d, want 1234 after 3 calls", bal.Balance, calls.Load())
+	}
+}
+
+func TestPostNotRetriedOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv)
+	_, err := c.CreateOrder(context.Background(), OrderRequest{Ticker: "X", Count: 1})
+	if !IsRetryable(err) {
+		t.Fatalf("err = %v, want retryable APIError surfaced", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("POST sent %d times, want 1", calls.Load())
+	}
+}
+
+func TestPostRetriedOnRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"order": {"order_id": "abc"}}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv)
+	order, err := c.CreateOrder(context.Background(), OrderRequest{Ticker: "X", Count: 1})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if order.OrderID != "abc" || calls.Load() != 2 {
+		t.Errorf("orderID=%q calls=%d", order.OrderID, calls.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"garbage", 0},
+		{now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffRespectsRetryAfter(t *testing.T) {
+	p := retryPolicy{MaxAttempts: 4, BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		if d := p.backoff(attempt, 0); d > p.MaxDelay {
+			t.Errorf("backoff(%d) = %v exceeds max %v", attempt, d, p.MaxDelay)
+		}
+	}
+	if d := p.backoff(0, 40*time.Millisecond); d < 40*time.Millisecond {
+		t.Errorf("backoff with Retry-After 40ms = %v, want at least 40ms", d)
+	}
+	if d := p.backoff(0, time.Minute); d != p.MaxDelay {
+		t.Errorf("backoff with Retry-After 1m = %v, want clamped to %v", d, p.MaxDelay)
+	}
+}
+
+func TestRateLimiterBlocksWhenEmpty(t *testing.T) {
+	l := newRateLimiter(10)
+	for i := 0; i < 10; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("token %d: reserve delay %v, want 0", i, d)
+		}
+	}
+	if d := l.reserve(); d <= 0 {
+		t.Errorf("empty bucket reserve = %v, want positive delay", d)
+	}
+}
+
+func TestGetOrderByClientID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ticker") != "T1" {
+			t.Errorf("ticker filter = %q", r.URL.Query().Get("ticker"))
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			w.Write([]byte(`{"orders":[{"order_id":"o1","client_order_id":"a"}],"cursor":"next"}`))
+			return
+		}
+		w.Write([]byte(`{"orders":[{"order_id":"o2","client_order_id":"b"}],"cursor":""}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv)
+	order, err := c.GetOrderByClientID(context.Background(), "T1", "b")
+	if err != nil {
+		t.Fatalf("GetOrderByClientID: %v", err)
+	}
+	if order.OrderID != "o2" {
+		t.Errorf("OrderID = %q, want o2", order.OrderID)
+	}
+
+	if _, err := c.GetOrderByClientID(context.Background(), "T1", "missing"); err != ErrOrderNotFound {
+		t.Errorf("missing order err = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestCancelOrderReturnsFinalState(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/trade-api/v2/portfolio/orders/o1" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"order":{"order_id":"o1","side":"no","status":"canceled","no_price":85,
+			"fill_count":4,"remaining_count":0,"taker_fill_cost":336,"taker_fees":4},"reduced_by":6}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv)
+	order, err := c.CancelOrder(context.Background(), "o1")
+	if err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	if !order.IsFinal() || order.FilledCount != 4 {
+		t.Errorf("order = %+v", order)
+	}
+	if got := order.AvgFillPrice(); got != 84 {
+		t.Errorf("AvgFillPrice = %d, want 84", got)
+	}
+	if got := order.FeesPaid(); got != 4 {
+		t.Errorf("FeesPaid = %d, want 4", got)
+	}
+}
+
+func TestBatchCreateOrdersPerOrderErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"orders":[
+			{"client_order_id":"a","order":{"order_id":"o1"}},
+			{"client_order_id":"b","error":{"code":"insufficient_balance","message":"no"}}]}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv)
+	results, err := c.BatchCreateOrders(context.Background(), []OrderRequest{{ClientOrderID: "a"}, {ClientOrderID: "b"}})
+	if err != nil {
+		t.Fatalf("BatchCreateOrders: %v", err)
+	}
+	if len(results) != 2 || results[0].Order == nil || results[0].Order.OrderID != "o1" {
+		t.Fatalf("results = %+v", results)
+	}
+	if results[1].Error == nil || results[1].Error.Code != "insufficient_balance" {
+		t.Errorf("second result error = %+v", results[1].Error)
+	}
+}
+
+func TestSettlementPnL(t *testing.T) {
+	st := Settlement{NoCount: 10, NoTotalCost: 850, Revenue: 1000, FeeCost: "0.0700"}
+	if got := st.FeeCents(); got != 7 {
+		t.Errorf("FeeCents = %d, want 7", got)
+	}
+	if got := st.PnL(); got != 143 {
+		t.Errorf("PnL = %d, want 143", got)
+	}
+
+	lost := Settlement{YesCount: 5, YesTotalCost: 400, Revenue: 0, FeeCost: "0.06"}
+	if got := lost.PnL(); got != -406 {
+		t.Errorf("losing PnL = %d, want -406", got)
+	}
+}
+
+func TestMaintenanceAt(t *testing.T) {
+	sched := ExchangeSchedule{MaintenanceWindows: []MaintenanceWindow{
+		{StartDatetime: "2026-02-12T08:00:00Z", EndDatetime: "2026-02-12T10:00:00Z"},
+	}}
+	tests := []struct {
+		at   string
+		want bool
+	}{
+		{"2026-02-12T07:59:59Z", false},
+		{"2026-02-12T08:00:00Z", true},
+		{"2026-02-12T09:30:00Z", true},
+		{"2026-02-12T10:00:00Z", false},
+	}
+	for _, tt := range tests {
+		at, _ := time.Parse(time.RFC3339, tt.at)
+		if got := sched.MaintenanceAt(at) != nil; got != tt.want {
+			t.Errorf("MaintenanceAt(%s) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package kalshi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is returned for any Kalshi response with status >= 400.
+type APIError struct {
+	StatusCode int           `json:"-"`
+	Code       string        `json:"code"`    // Kalshi error code, e.g. "insufficient_balance"
+	Message    string        `json:"message"` // human-readable message from the exchange
+	Body       string        `json:"-"`       // raw response body, for logging
+	RetryAfter time.Duration `json:"-"`       // parsed Retry-After header, 0 if absent
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("kalshi API error %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	if e.Message != "" {
+		return fmt.Sprintf("kalshi API error %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("kalshi API error %d: %s", e.StatusCode, e.Body)
+}
+
+// IsRateLimited reports whether the exchange rejected the request for
+// exceeding its rate limit.
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsRetryable reports whether the same request may succeed if sent again:
+// rate limiting and transient server-side failures.
+func (e *APIError) IsRetryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsRateLimited reports whether err wraps a 429 APIError.
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsRateLimited()
+}
+
+// IsRetryable reports whether err wraps an APIError worth retrying.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsRetryable()
+}
+
+// IsNotFound reports whether err wraps a 404 APIError.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsRejected reports whether err is a definitive client-side rejection
+// (4xx): the exchange received the request and did not act on it. Transport
+// errors and 5xx responses are ambiguous and return false.
+func IsRejected(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
+
+// IsDuplicateOrder reports whether an order was refused because its
+// client_order_id has already been used.
+func IsDuplicateOrder(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// newAPIError builds an APIError from a failed response. Kalshi wraps errors
+// as {"error": {"code": ..., "message": ...}}; anything else is kept raw.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+
+	var wrapped struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err == nil {
+		apiErr.Code = wrapped.Error.Code
+		apiErr.Message = wrapped.Error.Message
+	}
+	return apiErr
+}
+
+// parseRetryAfter accepts either delay-seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
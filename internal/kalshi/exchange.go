@@ -0,0 +1,80 @@
+package kalshi
+
+import (
+	"context"
+	"time"
+)
+
+// ExchangeStatus reports whether the exchange is up and accepting orders.
+type ExchangeStatus struct {
+	ExchangeActive              bool   `json:"exchange_active"`
+	TradingActive               bool   `json:"trading_active"`
+	ExchangeEstimatedResumeTime string `json:"exchange_estimated_resume_time"`
+}
+
+// DailyHours is one open session within a day, as "HH:MM" ET times.
+type DailyHours struct {
+	OpenTime  string `json:"open_time"`
+	CloseTime string `json:"close_time"`
+}
+
+// WeeklySchedule is the standard trading week in effect between StartTime
+// and EndTime.
+type WeeklySchedule struct {
+	StartTime string       `json:"start_time"`
+	EndTime   string       `json:"end_time"`
+	Monday    []DailyHours `json:"monday"`
+	Tuesday   []DailyHours `json:"tuesday"`
+	Wednesday []DailyHours `json:"wednesday"`
+	Thursday  []DailyHours `json:"thursday"`
+	Friday    []DailyHours `json:"friday"`
+	Saturday  []DailyHours `json:"saturday"`
+	Sunday    []DailyHours `json:"sunday"`
+}
+
+// MaintenanceWindow is a scheduled period during which trading is halted.
+type MaintenanceWindow struct {
+	StartDatetime string `json:"start_datetime"`
+	EndDatetime   string `json:"end_datetime"`
+}
+
+type ExchangeSchedule struct {
+	StandardHours      []WeeklySchedule    `json:"standard_hours"`
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows"`
+}
+
+// MaintenanceAt returns the maintenance window covering t, if any.
+func (s *ExchangeSchedule) MaintenanceAt(t time.Time) *MaintenanceWindow {
+	for i, w := range s.MaintenanceWindows {
+		start, err := time.Parse(time.RFC3339, w.StartDatetime)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, w.EndDatetime)
+		if err != nil {
+			continue
+		}
+		if !t.Before(start) && t.Before(end) {
+			return &s.MaintenanceWindows[i]
+		}
+	}
+	return nil
+}
+
+func (c *Client) GetExchangeStatus(ctx context.Context) (*ExchangeStatus, error) {
+	var result ExchangeStatus
+	if err := c.get(ctx, "/exchange/status", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetExchangeSchedule(ctx context.Context) (*ExchangeSchedule, error) {
+	var result struct {
+		Schedule ExchangeSchedule `json:"schedule"`
+	}
+	if err := c.get(ctx, "/exchange/schedule", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Schedule, nil
+}
@@ -0,0 +1,22 @@
+package kalshi
+
+import "math"
+
+// TakerFee computes the Kalshi taker fee in cents.
+// fee = ceil(0.07 * contracts * P * (1-P) * 100)
+// where P = priceCents / 100
+func TakerFee(contracts, priceCents int) int {
+	return int(math.Ceil(takerFeeRaw(contracts, priceCents)))
+}
+
+// takerFeeRaw is the fee in cents before rounding up.
+func takerFeeRaw(contracts, priceCents int) float64 {
+	p := float64(priceCents) / 100.0
+	return 0.07 * float64(contracts) * p * (1 - p) * 100.0
+}
+
+// MarginalTakerFee returns the unrounded taker fee in cents of one more
+// contract at priceCents within a larger order.
+func MarginalTakerFee(priceCents int) float64 {
+	return takerFeeRaw(1, priceCents)
+}
@@ -0,0 +1,118 @@
+package kalshi
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// OHLC is an open/low/high/close quartet in cents. Fields are pointers in
+// the API because a period with no activity has no prices.
+type OHLC struct {
+	Open  *int `json:"open"`
+	Low   *int `json:"low"`
+	High  *int `json:"high"`
+	Close *int `json:"close"`
+}
+
+// Candlestick is one period of market history.
+type Candlestick struct {
+	EndPeriodTs  int64 `json:"end_period_ts"`
+	YesBid       OHLC  `json:"yes_bid"`
+	YesAsk       OHLC  `json:"yes_ask"`
+	Price        OHLC  `json:"price"` // traded price
+	Volume       int   `json:"volume"`
+	OpenInterest int   `json:"open_interest"`
+}
+
+// maxCandlesPerRequest bounds how many periods we ask for in one call; longer
+// ranges are split into consecutive windows.
+const maxCandlesPerRequest = 5000
+
+// CandlesticksIter yields candlesticks for a market between start and end
+// at periodMinutes resolution (1, 60 or 1440), one request window at a time.
+// Each window starts where the last ended, so a candle ending exactly on the
+// boundary may be returned by both; it is only yielded from the first.
+func (c *Client) CandlesticksIter(ctx context.Context, seriesTicker, ticker string, start, end time.Time, periodMinutes int) iter.Seq2[[]Candlestick, error] {
+	period := time.Duration(periodMinutes) * time.Minute
+	window := period * maxCandlesPerRequest
+
+	return pages(ctx, func(ctx context.Context, cursor string) ([]Candlestick, string, error) {
+		from := start
+		if cursor != "" {
+			ts, err := strconv.ParseInt(cursor, 10, 64)
+			if err != nil {
+				return nil, "", fmt.Errorf("bad candlestick cursor %q: %w", cursor, err)
+			}
+			from = time.Unix(ts, 0)
+		}
+		to := from.Add(window)
+		if to.After(end) {
+			to = end
+		}
+
+		params := url.Values{}
+		params.Set("start_ts", strconv.FormatInt(from.Unix(), 10))
+		params.Set("end_ts", strconv.FormatInt(to.Unix(), 10))
+		params.Set("period_interval", strconv.Itoa(periodMinutes))
+
+		var result struct {
+			Candlesticks []Candlestick `json:"candlesticks"`
+		}
+		path := "/series/" + seriesTicker + "/markets/" + ticker + "/candlesticks"
+		if err := c.get(ctx, path, params, &result); err != nil {
+			return nil, "", err
+		}
+
+		candles := result.Candlesticks
+		if cursor != "" {
+			candles = slices.DeleteFunc(candles, func(c Candlestick) bool { return c.EndPeriodTs <= from.Unix() })
+		}
+
+		next := ""
+		if to.Before(end) {
+			next = strconv.FormatInt(to.Unix(), 10)
+		}
+		return candles, next, nil
+	})
+}
+
+// GetCandlesticks returns all candlesticks for a market between start and end.
+func (c *Client) GetCandlesticks(ctx context.Context, seriesTicker, ticker string, start, end time.Time, periodMinutes int) ([]Candlestick, error) {
+	return collect(c.CandlesticksIter(ctx, seriesTicker, ticker, start, end, periodMinutes))
+}
+
+// Trade is a single execution on the public tape.
+type Trade struct {
+	TradeID     string `json:"trade_id"`
+	Ticker      string `json:"ticker"`
+	Count       int    `json:"count"`
+	YesPrice    int    `json:"yes_price"`
+	NoPrice     int    `json:"no_price"`
+	TakerSide   string `json:"taker_side"`
+	CreatedTime string `json:"created_time"`
+}
+
+// TradesIter yields pages of the public /markets/trades tape (filters:
+// ticker, min_ts, max_ts).
+func (c *Client) TradesIter(ctx context.Context, params url.Values) iter.Seq2[[]Trade, error] {
+	return pages(ctx, func(ctx context.Context, cursor string) ([]Trade, string, error) {
+		var result struct {
+			Trades []Trade `json:"trades"`
+			Cursor string  `json:"cursor"`
+		}
+		if err := c.get(ctx, "/markets/trades", pageParams(params, cursor), &result); err != nil {
+			return nil, "", err
+		}
+		return result.Trades, result.Cursor, nil
+	})
+}
+
+// GetTrades returns every public trade matching params.
+func (c *Client) GetTrades(ctx context.Context, params url.Values) ([]Trade, error) {
+	return collect(c.TradesIter(ctx, params))
+}
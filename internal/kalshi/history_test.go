@@ -0,0 +1,103 @@
+package kalshi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCandlesticksIterSplitsWindows(t *testing.T) {
+	start := time.Unix(1_700_000_040, 0)
+	end := start.Add((2*maxCandlesPerRequest + 1) * time.Minute)
+
+	// Like Kalshi, return every period ending within [start_ts, end_ts]
+	var windows [][2]int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/trade-api/v2/series/S/markets/T/candlesticks" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		from, _ := strconv.ParseInt(r.URL.Query().Get("start_ts"), 10, 64)
+		to, _ := strconv.ParseInt(r.URL.Query().Get("end_ts"), 10, 64)
+		windows = append(windows, [2]int64{from, to})
+
+		var candles []Candlestick
+		for ts := from; ts <= to; ts += 60 {
+			candles = append(candles, Candlestick{EndPeriodTs: ts})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"candlesticks": candles})
+	}))
+	defer srv.Close()
+
+	got, err := newTestClient(t, srv).GetCandlesticks(context.Background(), "S", "T", start, end, 1)
+	if err != nil {
+		t.Fatalf("GetCandlesticks: %v", err)
+	}
+
+	window := int64(maxCandlesPerRequest * 60)
+	wantWindows := [][2]int64{
+		{start.Unix(), start.Unix() + window},
+		{start.Unix() + window, start.Unix() + 2*window},
+		{start.Unix() + 2*window, end.Unix()},
+	}
+	if !slices.Equal(windows, wantWindows) {
+		t.Errorf("windows = %v, want %v", windows, wantWindows)
+	}
+
+	// One candle per minute, boundaries included once
+	if len(got) != 2*maxCandlesPerRequest+2 {
+		t.Fatalf("got %d candles, want %d", len(got), 2*maxCandlesPerRequest+2)
+	}
+	for i, c := range got {
+		if want := start.Unix() + int64(i)*60; c.EndPeriodTs != want {
+			t.Fatalf("candle %d ends at %d, want %d", i, c.EndPeriodTs, want)
+		}
+	}
+}
+
+func TestTradesIterFollowsCursor(t *testing.T) {
+	book := map[string]struct {
+		ids  []string
+		next string
+	}{
+		"":   {ids: []string{"a", "b"}, next: "c1"},
+		"c1": {ids: []string{"c"}, next: "c2"},
+		"c2": {ids: []string{"d"}, next: ""},
+	}
+
+	var cursors []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/trade-api/v2/markets/trades" || q.Get("ticker") != "T" || q.Get("limit") != "200" {
+			t.Errorf("request = %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		cursors = append(cursors, q.Get("cursor"))
+		p := book[q.Get("cursor")]
+		var trades []Trade
+		for _, id := range p.ids {
+			trades = append(trades, Trade{TradeID: id, Ticker: "T"})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"trades": trades, "cursor": p.next})
+	}))
+	defer srv.Close()
+
+	got, err := newTestClient(t, srv).GetTrades(context.Background(), url.Values{"ticker": {"T"}})
+	if err != nil {
+		t.Fatalf("GetTrades: %v", err)
+	}
+	var ids []string
+	for _, tr := range got {
+		ids = append(ids, tr.TradeID)
+	}
+	if !slices.Equal(ids, []string{"a", "b", "c", "d"}) {
+		t.Errorf("trades = %v", ids)
+	}
+	if !slices.Equal(cursors, []string{"", "c1", "c2"}) {
+		t.Errorf("cursors followed = %q", cursors)
+	}
+}
@@ -0,0 +1,366 @@
+// Package kalshitest runs an in-process fake of the Kalshi trade API for
+// tests. It serves the REST endpoints and WebSocket channels the bot uses,
+// verifies request signatures, and keeps scripted markets, books, orders,
+// fills, positions and settlements. Point a Config at it with
+// KalshiBaseURL = Exchange.URL and KalshiWSURL = Exchange.WSURL.
+package kalshitest
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// Paths the fake serves under, matching the real exchange.
+const (
+	restPath = "/trade-api/v2"
+	wsPath   = "/trade-api/ws/v2"
+)
+
+// Exchange is a fake Kalshi exchange. Markets, books and the account are
+// scripted through its methods; orders sent by a client match against the
+// scripted books and produce fills, WS pushes and, once a market is
+// settled, settlement records.
+type Exchange struct {
+	URL   string // REST base URL, e.g. http://127.0.0.1:1234/trade-api/v2
+	WSURL string // WebSocket URL, e.g. ws://127.0.0.1:1234/trade-api/ws/v2
+
+	srv      *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	status      kalshi.ExchangeStatus
+	schedule    kalshi.ExchangeSchedule
+	series      map[string]kalshi.Series
+	markets     map[string]*market
+	tickers     []string // market tickers in creation order
+	balance     int
+	orders      []*kalshi.Order
+	fills       []kalshi.Fill
+	positions   map[string]*position
+	settlements []kalshi.Settlement
+	nextID      int
+	conns       map[*wsConn]bool
+	nextSid     int64
+}
+
+type market struct {
+	kalshi.Market
+	book book
+}
+
+// book holds resting bid quantity by price (1–99c) for each side.
+type book struct {
+	yes, no [100]int
+}
+
+func (b *book) side(side string) *[100]int {
+	if side == "yes" {
+		return &b.yes
+	}
+	return &b.no
+}
+
+// levels returns the side's bids best (highest price) first, in the
+// [[price, quantity], ...] wire format.
+func (b *book) levels(side string) [][]int {
+	qty := b.side(side)
+	out := [][]int{}
+	for p := 99; p >= 1; p-- {
+		if qty[p] > 0 {
+			out = append(out, []int{p, qty[p]})
+		}
+	}
+	return out
+}
+
+// position is the account's holding in one market.
+type position struct {
+	yes, no         int // contracts held
+	yesCost, noCost int // cents paid for the contracts held
+	fees            int // cents of fees paid in the market
+}
+
+// New starts a fake exchange. It is trading, with no markets and a zero
+// balance. Call Close when done.
+func New() *Exchange {
+	ex := &Exchange{
+		keys:      make(map[string]*rsa.PublicKey),
+		status:    kalshi.ExchangeStatus{ExchangeActive: true, TradingActive: true},
+		series:    make(map[string]kalshi.Series),
+		markets:   make(map[string]*market),
+		positions: make(map[string]*position),
+		conns:     make(map[*wsConn]bool),
+	}
+
+	mux := http.NewServeMux()
+	ex.routes(mux)
+	mux.HandleFunc("GET "+wsPath, ex.serveWS)
+	ex.srv = httptest.NewServer(ex.authenticate(mux))
+
+	ex.URL = ex.srv.URL + restPath
+	ex.WSURL = "ws" + strings.TrimPrefix(ex.srv.URL, "http") + wsPath
+	return ex
+}
+
+// Close disconnects every WS client and shuts the server down.
+func (ex *Exchange) Close() {
+	ex.Disconnect()
+	ex.srv.Close()
+}
+
+// AddKey registers an API key. Requests must be signed by a registered key.
+func (ex *Exchange) AddKey(keyID string, pub *rsa.PublicKey) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	ex.keys[keyID] = pub
+}
+
+// SetBalance sets the account's available balance in cents.
+func (ex *Exchange) SetBalance(cents int) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	ex.balance = cents
+}
+
+// Balance returns the account's available balance in cents.
+func (ex *Exchange) Balance() int {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	return ex.balance
+}
+
+// SetStatus sets what /exchange/status reports. While trading is inactive
+// new orders are rejected.
+func (ex *Exchange) SetStatus(status kalshi.ExchangeStatus) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	ex.status = status
+}
+
+// SetSchedule sets what /exchange/schedule reports.
+func (ex *Exchange) SetSchedule(schedule kalshi.ExchangeSchedule) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	ex.schedule = schedule
+}
+
+// AddSeries registers series metadata for /series/{ticker}.
+func (ex *Exchange) AddSeries(s kalshi.Series) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	ex.series[s.Ticker] = s
+}
+
+// AddMarket lists a market with an empty book. Status defaults to "open".
+// Lifecycle subscribers are sent a "created" event.
+func (ex *Exchange) AddMarket(m kalshi.Market) {
+	if m.Status == "" {
+		m.Status = "open"
+	}
+
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	if _, ok := ex.markets[m.Ticker]; !ok {
+		ex.tickers = append(ex.tickers, m.Ticker)
+	}
+	ex.markets[m.Ticker] = &market{Market: m}
+
+	lc := kalshi.LifecycleEvent{Ticker: m.Ticker, EventType: "created"}
+	if t, err := m.CloseTimeParsed(); err == nil && !t.IsZero() {
+		lc.CloseTS = t.Unix()
+	}
+	ex.push("market_lifecycle_v2", m.Ticker, lc)
+}
+
+// SetBook replaces a market's resting liquidity. Levels are bids on each
+// side; a YES bid at p is a NO ask at 100-p. Book subscribers get a delta
+// per changed level, and resting orders that the new liquidity crosses
+// fill at their limit price.
+func (ex *Exchange) SetBook(ticker string, yes, no []kalshi.PriceLevel) error {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	m := ex.markets[ticker]
+	if m == nil {
+		return fmt.Errorf("kalshitest: unknown market %s", ticker)
+	}
+	var next book
+	for side, levels := range map[string][]kalshi.PriceLevel{"yes": yes, "no": no} {
+		for _, l := range levels {
+			if l.Price < 1 || l.Price > 99 || l.Quantity < 0 {
+				return fmt.Errorf("kalshitest: bad %s level %+v", side, l)
+			}
+			next.side(side)[l.Price] += l.Quantity
+		}
+	}
+
+	for _, side := range []string{"yes", "no"} {
+		for p := 1; p <= 99; p++ {
+			if d := next.side(side)[p] - m.book.side(side)[p]; d != 0 {
+				ex.changeBook(m, side, p, d)
+			}
+		}
+	}
+
+	for _, o := range ex.orders {
+		if o.Ticker == ticker && o.Status == "resting" && ex.match(m, o, false) > 0 {
+			ex.push("user_order", o.Ticker, *o)
+		}
+	}
+	return nil
+}
+
+// Settle determines a market. Every position in it is paid out and
+// recorded as a settlement, resting orders are canceled, and lifecycle
+// subscribers are sent "determined" and "settled" events.
+func (ex *Exchange) Settle(ticker, result string) error {
+	if result != "yes" && result != "no" {
+		return fmt.Errorf("kalshitest: result must be yes or no, got %q", result)
+	}
+
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	m := ex.markets[ticker]
+	if m == nil {
+		return fmt.Errorf("kalshitest: unknown market %s", ticker)
+	}
+
+	for _, o := range ex.orders {
+		if o.Ticker == ticker && o.Status == "resting" {
+			ex.cancel(o)
+		}
+	}
+
+	now := time.Now()
+	m.Status = "settled"
+	m.Result = result
+	ex.push("market_lifecycle_v2", ticker, kalshi.LifecycleEvent{
+		Ticker: ticker, EventType: "determined", Result: result, DeterminationTS: now.Unix(),
+	})
+
+	if pos := ex.positions[ticker]; pos != nil && (pos.yes > 0 || pos.no > 0) {
+		won := pos.no
+		if result == "yes" {
+			won = pos.yes
+		}
+		revenue := 100 * won
+		ex.balance += revenue
+		ex.settlements = append(ex.settlements, kalshi.Settlement{
+			Ticker:       ticker,
+			MarketResult: result,
+			YesCount:     pos.yes,
+			YesTotalCost: pos.yesCost,
+			NoCount:      pos.no,
+			NoTotalCost:  pos.noCost,
+			Revenue:      revenue,
+			FeeCost:      fmt.Sprintf("%.4f", float64(pos.fees)/100),
+			SettledTime:  now.UTC().Format(time.RFC3339),
+		})
+		delete(ex.positions, ticker)
+	}
+
+	ex.push("market_lifecycle_v2", ticker, kalshi.LifecycleEvent{
+		Ticker: ticker, EventType: "settled", Result: result, SettledTS: now.Unix(),
+	})
+	return nil
+}
+
+// Buy fills a buy of count contracts of side at price for the account,
+// bypassing the book, as if the order had been placed before the test
+// began. It returns the executed order.
+func (ex *Exchange) Buy(ticker, side string, count, price int) (kalshi.Order, error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	if ex.markets[ticker] == nil {
+		return kalshi.Order{}, fmt.Errorf("kalshitest: unknown market %s", ticker)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	o := &kalshi.Order{
+		OrderID:        ex.id("ord"),
+		Ticker:         ticker,
+		Action:         "buy",
+		Side:           side,
+		Type:           "limit",
+		YesPrice:       price,
+		NoPrice:        100 - price,
+		InitialCount:   count,
+		RemainingCount: count,
+		CreatedTime:    now,
+	}
+	if side == "no" {
+		o.YesPrice, o.NoPrice = o.NoPrice, o.YesPrice
+	}
+	ex.orders = append(ex.orders, o)
+	ex.fill(o, count, price, true)
+	o.Status = "executed"
+	ex.push("user_order", ticker, *o)
+	return *o, nil
+}
+
+// Orders returns every order placed, oldest first.
+func (ex *Exchange) Orders() []kalshi.Order {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	out := make([]kalshi.Order, len(ex.orders))
+	for i, o := range ex.orders {
+		out[i] = *o
+	}
+	return out
+}
+
+// Fills returns every fill, oldest first.
+func (ex *Exchange) Fills() []kalshi.Fill {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	return slices.Clone(ex.fills)
+}
+
+// Settlements returns every settlement record, oldest first.
+func (ex *Exchange) Settlements() []kalshi.Settlement {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	return slices.Clone(ex.settlements)
+}
+
+// Disconnect drops every WS connection, as a network failure would.
+func (ex *Exchange) Disconnect() {
+	ex.mu.Lock()
+	conns := make([]*wsConn, 0, len(ex.conns))
+	for c := range ex.conns {
+		conns = append(conns, c)
+	}
+	ex.mu.Unlock()
+
+	for _, c := range conns {
+		c.close()
+	}
+}
+
+// id returns a fresh identifier with prefix. Caller holds mu.
+func (ex *Exchange) id(prefix string) string {
+	ex.nextID++
+	return fmt.Sprintf("%s-%06d", prefix, ex.nextID)
+}
+
+// changeBook adjusts one level and publishes the delta. Caller holds mu.
+func (ex *Exchange) changeBook(m *market, side string, price, delta int) {
+	m.book.side(side)[price] += delta
+	ex.push("orderbook_delta", m.Ticker, map[string]any{
+		"market_ticker": m.Ticker,
+		"price":         price,
+		"delta":         delta,
+		"side":          side,
+	})
+}
@@ -0,0 +1,210 @@
+package kalshitest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+const testTicker = "KXBTC15M-26OCT161200-00"
+
+// newTestExchange starts an exchange with one open market and returns a
+// config pointing at it and a signer for a registered key.
+func newTestExchange(t *testing.T) (*Exchange, *config.Config, kalshi.Signer) {
+	t.Helper()
+	ex := New()
+	t.Cleanup(ex.Close)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	ex.AddKey("test-key", &key.PublicKey)
+	ex.AddMarket(kalshi.Market{
+		Ticker:    testTicker,
+		CloseTime: time.Now().Add(10 * time.Minute).UTC().Format(time.RFC3339),
+	})
+
+	cfg := &config.Config{
+		KalshiAPIKeyID: "test-key",
+		Series:         []string{"KXBTC15M"},
+		KalshiBaseURL:  ex.URL,
+		KalshiWSURL:    ex.WSURL,
+	}
+	return ex, cfg, kalshi.NewKeySigner("test-key", key)
+}
+
+func newTestClient(t *testing.T, cfg *config.Config, signer kalshi.Signer) *kalshi.Client {
+	t.Helper()
+	c, err := kalshi.NewClient(cfg, signer)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func TestRejectsUnknownKey(t *testing.T) {
+	_, cfg, _ := newTestExchange(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	c := newTestClient(t, cfg, kalshi.NewKeySigner("test-key", other))
+	_, err = c.GetBalance(context.Background())
+	var apiErr *kalshi.APIError
+	if !kalshi.IsRejected(err) || !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Fatalf("err = %v, want 401 for a signature by the wrong key", err)
+	}
+}
+
+func TestOrderMatchingAndSettlement(t *testing.T) {
+	ex, cfg, signer := newTestExchange(t)
+	ctx := context.Background()
+	c := newTestClient(t, cfg, signer)
+
+	ex.SetBalance(10_000)
+	// YES asks: 50 @ 85, 100 @ 86
+	if err := ex.SetBook(testTicker, []kalshi.PriceLevel{{Price: 80, Quantity: 20}}, []kalshi.PriceLevel{{Price: 15, Quantity: 50}, {Price: 14, Quantity: 100}}); err != nil {
+		t.Fatalf("SetBook: %v", err)
+	}
+
+	o, err := c.CreateOrder(ctx, kalshi.OrderRequest{
+		Ticker: testTicker, ClientOrderID: "c1", Action: "buy", Side: "yes", Type: "limit", Count: 60, YesPrice: 86,
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	fee := kalshi.TakerFee(50, 85) + kalshi.TakerFee(10, 86)
+	if o.Status != "executed" || o.FilledCount != 60 || o.TakerFillCost != 50*85+10*86 || o.TakerFees != fee {
+		t.Errorf("order = %+v", o)
+	}
+	if got, want := ex.Balance(), 10_000-5110-fee; got != want {
+		t.Errorf("balance = %d, want %d", got, want)
+	}
+
+	_, err = c.CreateOrder(ctx, kalshi.OrderRequest{
+		Ticker: testTicker, ClientOrderID: "c1", Action: "buy", Side: "yes", Type: "limit", Count: 1, YesPrice: 86,
+	})
+	if !kalshi.IsDuplicateOrder(err) {
+		t.Errorf("reused client order id: err = %v, want duplicate", err)
+	}
+
+	positions, err := c.GetPositions(ctx, "")
+	if err != nil || len(positions) != 1 || positions[0].Position != 60 {
+		t.Fatalf("positions = %+v, %v", positions, err)
+	}
+	fills, err := c.GetFills(ctx, url.Values{"ticker": {testTicker}})
+	if err != nil || len(fills) != 2 || fills[0].YesPrice != 85 || fills[1].Count != 10 {
+		t.Fatalf("fills = %+v, %v", fills, err)
+	}
+
+	if err := ex.Settle(testTicker, "yes"); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	settlements, err := c.GetSettlements(ctx, nil)
+	if err != nil || len(settlements) != 1 {
+		t.Fatalf("settlements = %+v, %v", settlements, err)
+	}
+	st := settlements[0]
+	if st.Revenue != 6000 || st.YesCount != 60 || st.FeeCents() != fee || st.PnL() != 6000-5110-fee {
+		t.Errorf("settlement = %+v, pnl %d", st, st.PnL())
+	}
+	if m, err := c.GetMarket(ctx, testTicker); err != nil || m.Result != "yes" {
+		t.Errorf("market = %+v, %v", m, err)
+	}
+}
+
+func TestRestingOrderFillsOnNewLiquidity(t *testing.T) {
+	ex, cfg, signer := newTestExchange(t)
+	ctx := context.Background()
+	c := newTestClient(t, cfg, signer)
+	ex.SetBalance(10_000)
+
+	o, err := c.CreateOrder(ctx, kalshi.OrderRequest{
+		Ticker: testTicker, Action: "buy", Side: "no", Type: "limit", Count: 10, NoPrice: 30,
+	})
+	if err != nil || o.Status != "resting" {
+		t.Fatalf("order = %+v, %v; want resting on an empty book", o, err)
+	}
+
+	// A YES bid at 72 is a NO ask at 28: it crosses, filling at our 30
+	ex.SetBook(testTicker, []kalshi.PriceLevel{{Price: 72, Quantity: 4}}, nil)
+	o, err = c.GetOrder(ctx, o.OrderID)
+	if err != nil || o.FilledCount != 4 || o.MakerFillCost != 120 || o.Status != "resting" {
+		t.Fatalf("order = %+v, %v; want 4 filled as maker", o, err)
+	}
+
+	o, err = c.CancelOrder(ctx, o.OrderID)
+	if err != nil || o.Status != "canceled" || o.FilledCount != 4 {
+		t.Fatalf("canceled order = %+v, %v", o, err)
+	}
+	if _, err := c.CancelOrder(ctx, o.OrderID); !kalshi.IsNotFound(err) {
+		t.Errorf("second cancel: err = %v, want not found", err)
+	}
+}
+
+func TestWSStreamsBookAndAccount(t *testing.T) {
+	ex, cfg, signer := newTestExchange(t)
+	ex.SetBalance(10_000)
+	ex.SetBook(testTicker, []kalshi.PriceLevel{{Price: 40, Quantity: 10}}, []kalshi.PriceLevel{{Price: 55, Quantity: 5}})
+
+	ws, err := kalshi.NewWSClient(cfg, signer)
+	if err != nil {
+		t.Fatalf("NewWSClient: %v", err)
+	}
+	orders := ws.SubscribeEvents(kalshi.EventFilter{Kinds: []kalshi.EventKind{kalshi.EventOrder}})
+	defer orders.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ws.Run(ctx)
+
+	waitFor(t, "connection", func() bool { return ws.Health().Connected })
+	if err := ws.Subscribe([]string{testTicker}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	waitFor(t, "snapshot", func() bool {
+		ob := ws.GetOrderbook(testTicker)
+		return ob != nil && ob.BestYesBid() == 40 && ob.BestYesAsk() == 45
+	})
+
+	ex.SetBook(testTicker, []kalshi.PriceLevel{{Price: 42, Quantity: 3}}, []kalshi.PriceLevel{{Price: 55, Quantity: 5}, {Price: 54, Quantity: 8}})
+	waitFor(t, "deltas", func() bool {
+		ob := ws.GetOrderbook(testTicker)
+		return ob != nil && ob.BestYesBid() == 42 && ob.Quantity("no", 54) == 8
+	})
+
+	if _, err := ex.Buy(testTicker, "yes", 2, 45); err != nil {
+		t.Fatalf("Buy: %v", err)
+	}
+	select {
+	case ev := <-orders.C:
+		if ev.Order.Status != "executed" || ev.Order.FilledCount != 2 {
+			t.Errorf("order push = %+v", ev.Order)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no user_order push")
+	}
+	if ws.SeqGaps() != 0 {
+		t.Errorf("seq gaps = %d, want 0", ws.SeqGaps())
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,281 @@
+package kalshitest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// createOrder validates req, matches it against the book and rests or
+// cancels the remainder according to its time in force. Caller holds mu.
+func (ex *Exchange) createOrder(req kalshi.OrderRequest) (*kalshi.Order, *apiError) {
+	bad := func(code, msg string) (*kalshi.Order, *apiError) {
+		return nil, &apiError{http.StatusBadRequest, code, msg}
+	}
+
+	if !ex.status.ExchangeActive || !ex.status.TradingActive {
+		return bad("trading_is_paused", "trading is paused")
+	}
+	m := ex.markets[req.Ticker]
+	if m == nil {
+		return nil, notFound("market")
+	}
+	if closeTime, _ := m.CloseTimeParsed(); m.Status != "open" || !closeTime.IsZero() && time.Now().After(closeTime) {
+		return bad("market_closed", "market is not open for trading")
+	}
+	if req.Action != "buy" && req.Action != "sell" || req.Side != "yes" && req.Side != "no" || req.Count <= 0 {
+		return bad("invalid_parameters", "action, side and count are required")
+	}
+
+	limit := req.YesPrice
+	if req.Side == "no" {
+		limit = req.NoPrice
+	}
+	if req.Type == "market" && limit == 0 {
+		limit = 99
+		if req.Action == "sell" {
+			limit = 1
+		}
+	}
+	if limit < 1 || limit > 99 {
+		return bad("invalid_parameters", "price must be between 1 and 99")
+	}
+
+	if req.ClientOrderID != "" {
+		for _, o := range ex.orders {
+			if o.ClientOrderID == req.ClientOrderID {
+				return nil, &apiError{http.StatusConflict, "order_already_exists", "client_order_id already used"}
+			}
+		}
+	}
+
+	pos := ex.position(req.Ticker)
+	if req.Action == "buy" && ex.balance < req.Count*limit+kalshi.TakerFee(req.Count, limit) {
+		return bad("insufficient_balance", "insufficient balance")
+	}
+	if req.Action == "sell" && *pos.held(req.Side) < req.Count {
+		return bad("insufficient_position", "cannot sell more contracts than held")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	o := &kalshi.Order{
+		OrderID:        ex.id("ord"),
+		ClientOrderID:  req.ClientOrderID,
+		Ticker:         req.Ticker,
+		Status:         "resting",
+		Action:         req.Action,
+		Side:           req.Side,
+		Type:           req.Type,
+		YesPrice:       limit,
+		NoPrice:        100 - limit,
+		InitialCount:   req.Count,
+		RemainingCount: req.Count,
+		CreatedTime:    now,
+		LastUpdateTime: now,
+	}
+	if req.Side == "no" {
+		o.YesPrice, o.NoPrice = o.NoPrice, o.YesPrice
+	}
+	ex.orders = append(ex.orders, o)
+
+	if req.TimeInForce != "fill_or_kill" || ex.available(m, o) >= o.RemainingCount {
+		ex.match(m, o, true)
+	}
+	if o.RemainingCount > 0 && (req.Type == "market" || req.TimeInForce == "immediate_or_cancel" || req.TimeInForce == "fill_or_kill") {
+		ex.cancel(o)
+	} else {
+		ex.push("user_order", o.Ticker, *o)
+	}
+	return o, nil
+}
+
+// crossing yields the book levels o can trade with, best first: the side
+// of the book and price to take from, and the price in o's terms.
+func crossing(m *market, o *kalshi.Order, yield func(bookSide string, bookPrice, price int) bool) {
+	limit := o.YesPrice
+	if o.Side == "no" {
+		limit = o.NoPrice
+	}
+
+	// Buying lifts the other side's bids at 100 minus their price; selling
+	// hits bids on the same side.
+	bookSide := o.Side
+	if o.Action == "buy" {
+		bookSide = "yes"
+		if o.Side == "yes" {
+			bookSide = "no"
+		}
+	}
+	qty := m.book.side(bookSide)
+	for p := 99; p >= 1; p-- {
+		price := p
+		if o.Action == "buy" {
+			price = 100 - p
+		}
+		if o.Action == "buy" && price > limit || o.Action == "sell" && price < limit {
+			return
+		}
+		if qty[p] > 0 && !yield(bookSide, p, price) {
+			return
+		}
+	}
+}
+
+// available returns how many contracts o could fill right now.
+func (ex *Exchange) available(m *market, o *kalshi.Order) int {
+	n := 0
+	crossing(m, o, func(bookSide string, bookPrice, _ int) bool {
+		n += m.book.side(bookSide)[bookPrice]
+		return true
+	})
+	return n
+}
+
+// match fills o against the book. A taker trades at each level's price; a
+// resting order reached by new liquidity trades at its own limit. Returns
+// the contracts filled. Caller holds mu.
+func (ex *Exchange) match(m *market, o *kalshi.Order, taker bool) int {
+	limit := o.YesPrice
+	if o.Side == "no" {
+		limit = o.NoPrice
+	}
+
+	filled := 0
+	crossing(m, o, func(bookSide string, bookPrice, price int) bool {
+		n := min(m.book.side(bookSide)[bookPrice], o.RemainingCount)
+		if !taker {
+			price = limit
+		}
+		ex.changeBook(m, bookSide, bookPrice, -n)
+		ex.fill(o, n, price, taker)
+		filled += n
+		return o.RemainingCount > 0
+	})
+	if o.RemainingCount == 0 {
+		o.Status = "executed"
+	}
+	return filled
+}
+
+// fill executes n contracts of o at price (in o's side's terms), updating
+// the order, balance and position and publishing the fill and the public
+// trade. Caller holds mu.
+func (ex *Exchange) fill(o *kalshi.Order, n, price int, taker bool) {
+	cost := n * price
+	fee := 0
+	if taker {
+		fee = kalshi.TakerFee(n, price)
+	}
+
+	pos := ex.position(o.Ticker)
+	held, paid := pos.held(o.Side), pos.paid(o.Side)
+	if o.Action == "buy" {
+		ex.balance -= cost + fee
+		*held += n
+		*paid += cost
+	} else {
+		ex.balance += cost - fee
+		*paid -= *paid * n / *held
+		*held -= n
+	}
+	pos.fees += fee
+
+	o.FilledCount += n
+	o.RemainingCount -= n
+	if taker {
+		o.TakerFillCost += cost
+		o.TakerFees += fee
+	} else {
+		o.MakerFillCost += cost
+		o.MakerFees += fee
+	}
+	o.LastUpdateTime = time.Now().UTC().Format(time.RFC3339)
+
+	yesPrice := price
+	if o.Side == "no" {
+		yesPrice = 100 - price
+	}
+	f := kalshi.Fill{
+		FillID:      ex.id("fill"),
+		OrderID:     o.OrderID,
+		Ticker:      o.Ticker,
+		Side:        o.Side,
+		Action:      o.Action,
+		Count:       n,
+		YesPrice:    yesPrice,
+		NoPrice:     100 - yesPrice,
+		IsTaker:     taker,
+		CreatedTime: o.LastUpdateTime,
+	}
+	ex.fills = append(ex.fills, f)
+
+	now := time.Now().Unix()
+	ex.push("fill", o.Ticker, kalshi.FillUpdate{
+		TradeID:       f.FillID,
+		OrderID:       o.OrderID,
+		ClientOrderID: o.ClientOrderID,
+		Ticker:        o.Ticker,
+		IsTaker:       taker,
+		Side:          o.Side,
+		Action:        o.Action,
+		YesPrice:      f.YesPrice,
+		NoPrice:       f.NoPrice,
+		Count:         n,
+		TS:            now,
+	})
+
+	// The taker side is the side whose contracts the aggressor bought.
+	takerSide := o.Side
+	if (o.Action == "sell") == taker {
+		takerSide = "yes"
+		if o.Side == "yes" {
+			takerSide = "no"
+		}
+	}
+	ex.push("trade", o.Ticker, kalshi.TradeUpdate{
+		TradeID:   f.FillID,
+		Ticker:    o.Ticker,
+		YesPrice:  f.YesPrice,
+		NoPrice:   f.NoPrice,
+		Count:     n,
+		TakerSide: takerSide,
+		TS:        now,
+	})
+}
+
+// cancel cancels o's remainder and returns how many contracts it removed.
+// Caller holds mu.
+func (ex *Exchange) cancel(o *kalshi.Order) int {
+	reduced := o.RemainingCount
+	o.RemainingCount = 0
+	o.Status = "canceled"
+	o.LastUpdateTime = time.Now().UTC().Format(time.RFC3339)
+	ex.push("user_order", o.Ticker, *o)
+	return reduced
+}
+
+// position returns the account's position in ticker, creating it. Caller
+// holds mu.
+func (ex *Exchange) position(ticker string) *position {
+	pos := ex.positions[ticker]
+	if pos == nil {
+		pos = &position{}
+		ex.positions[ticker] = pos
+	}
+	return pos
+}
+
+func (p *position) held(side string) *int {
+	if side == "yes" {
+		return &p.yes
+	}
+	return &p.no
+}
+
+func (p *position) paid(side string) *int {
+	if side == "yes" {
+		return &p.yesCost
+	}
+	return &p.noCost
+}
@@ -0,0 +1,270 @@
+package kalshitest
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// signatureMaxAge is how far a request's signed timestamp may be from the
+// fake's clock.
+const signatureMaxAge = 30 * time.Second
+
+// authenticate rejects requests not signed by a registered key, the way the
+// exchange does: an RSA-PSS signature over timestamp + method + path.
+func (ex *Exchange) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := ex.verify(r); err != nil {
+			writeError(w, http.StatusUnauthorized, "authentication_error", err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ex *Exchange) verify(r *http.Request) error {
+	keyID := r.Header.Get("KALSHI-ACCESS-KEY")
+	ts := r.Header.Get("KALSHI-ACCESS-TIMESTAMP")
+
+	ex.mu.Lock()
+	pub := ex.keys[keyID]
+	ex.mu.Unlock()
+	if pub == nil {
+		return fmt.Errorf("unknown key %q", keyID)
+	}
+
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad timestamp %q", ts)
+	}
+	if age := time.Since(time.UnixMilli(ms)); age > signatureMaxAge || age < -signatureMaxAge {
+		return fmt.Errorf("timestamp off by %s", age.Round(time.Millisecond))
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get("KALSHI-ACCESS-SIGNATURE"))
+	if err != nil {
+		return fmt.Errorf("bad signature encoding: %w", err)
+	}
+	hash := sha256.Sum256([]byte(ts + r.Method + r.URL.Path))
+	if err := rsa.VerifyPSS(pub, crypto.SHA256, hash[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// apiError is a rejection in the exchange's error format.
+type apiError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	writeJSON(w, status, map[string]any{"error": map[string]string{"code": code, "message": msg}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// routes registers the REST endpoints.
+func (ex *Exchange) routes(mux *http.ServeMux) {
+	handle := func(pattern string, h func(r *http.Request) (any, *apiError)) {
+		method, path, _ := strings.Cut(pattern, " ")
+		mux.HandleFunc(method+" "+restPath+path, func(w http.ResponseWriter, r *http.Request) {
+			ex.mu.Lock()
+			v, apiErr := h(r)
+			ex.mu.Unlock()
+			if apiErr != nil {
+				writeError(w, apiErr.status, apiErr.code, apiErr.msg)
+				return
+			}
+			writeJSON(w, http.StatusOK, v)
+		})
+	}
+
+	handle("GET /exchange/status", func(r *http.Request) (any, *apiError) {
+		return ex.status, nil
+	})
+	handle("GET /exchange/schedule", func(r *http.Request) (any, *apiError) {
+		return map[string]any{"schedule": ex.schedule}, nil
+	})
+
+	handle("GET /series/{ticker}", func(r *http.Request) (any, *apiError) {
+		s, ok := ex.series[r.PathValue("ticker")]
+		if !ok {
+			return nil, notFound("series")
+		}
+		return map[string]any{"series": s}, nil
+	})
+
+	handle("GET /markets", func(r *http.Request) (any, *apiError) {
+		q := r.URL.Query()
+		var out []kalshi.Market
+		for _, t := range ex.tickers {
+			m := ex.markets[t].Market
+			if s := q.Get("series_ticker"); s != "" && !strings.HasPrefix(m.Ticker, s+"-") {
+				continue
+			}
+			if s := q.Get("status"); s != "" && m.Status != s {
+				continue
+			}
+			out = append(out, m)
+		}
+		page, cursor := paginate(out, q)
+		return map[string]any{"markets": page, "cursor": cursor}, nil
+	})
+	handle("GET /markets/{ticker}", func(r *http.Request) (any, *apiError) {
+		m := ex.markets[r.PathValue("ticker")]
+		if m == nil {
+			return nil, notFound("market")
+		}
+		return map[string]any{"market": m.Market}, nil
+	})
+	handle("GET /markets/{ticker}/orderbook", func(r *http.Request) (any, *apiError) {
+		m := ex.markets[r.PathValue("ticker")]
+		if m == nil {
+			return nil, notFound("market")
+		}
+		yes, no := m.book.levels("yes"), m.book.levels("no")
+		if d, err := strconv.Atoi(r.URL.Query().Get("depth")); err == nil && d > 0 {
+			yes, no = yes[:min(d, len(yes))], no[:min(d, len(no))]
+		}
+		return map[string]any{"orderbook": map[string]any{"ticker": m.Ticker, "yes": yes, "no": no}}, nil
+	})
+
+	handle("GET /portfolio/balance", func(r *http.Request) (any, *apiError) {
+		return kalshi.Balance{Balance: ex.balance}, nil
+	})
+	handle("GET /portfolio/positions", func(r *http.Request) (any, *apiError) {
+		var out []kalshi.Position
+		for _, t := range ex.tickers {
+			pos := ex.positions[t]
+			if pos == nil || pos.yes == 0 && pos.no == 0 {
+				continue
+			}
+			out = append(out, kalshi.Position{
+				Ticker:         t,
+				MarketExposure: pos.yesCost + pos.noCost,
+				Position:       pos.yes - pos.no,
+			})
+		}
+		page, cursor := paginate(out, r.URL.Query())
+		return map[string]any{"market_positions": page, "cursor": cursor}, nil
+	})
+	handle("GET /portfolio/fills", func(r *http.Request) (any, *apiError) {
+		q := r.URL.Query()
+		var out []kalshi.Fill
+		for _, f := range ex.fills {
+			if matches(q, "ticker", f.Ticker) && matches(q, "order_id", f.OrderID) {
+				out = append(out, f)
+			}
+		}
+		page, cursor := paginate(out, q)
+		return map[string]any{"fills": page, "cursor": cursor}, nil
+	})
+	handle("GET /portfolio/settlements", func(r *http.Request) (any, *apiError) {
+		q := r.URL.Query()
+		var out []kalshi.Settlement
+		for _, s := range ex.settlements {
+			if matches(q, "ticker", s.Ticker) {
+				out = append(out, s)
+			}
+		}
+		page, cursor := paginate(out, q)
+		return map[string]any{"settlements": page, "cursor": cursor}, nil
+	})
+
+	handle("GET /portfolio/orders", func(r *http.Request) (any, *apiError) {
+		q := r.URL.Query()
+		var out []kalshi.Order
+		for _, o := range ex.orders {
+			if matches(q, "ticker", o.Ticker) && matches(q, "status", o.Status) {
+				out = append(out, *o)
+			}
+		}
+		page, cursor := paginate(out, q)
+		return map[string]any{"orders": page, "cursor": cursor}, nil
+	})
+	handle("GET /portfolio/orders/{id}", func(r *http.Request) (any, *apiError) {
+		o := ex.order(r.PathValue("id"))
+		if o == nil {
+			return nil, notFound("order")
+		}
+		return map[string]any{"order": *o}, nil
+	})
+	handle("POST /portfolio/orders", func(r *http.Request) (any, *apiError) {
+		var req kalshi.OrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, &apiError{http.StatusBadRequest, "invalid_parameters", err.Error()}
+		}
+		o, apiErr := ex.createOrder(req)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return map[string]any{"order": *o}, nil
+	})
+	handle("DELETE /portfolio/orders/{id}", func(r *http.Request) (any, *apiError) {
+		o := ex.order(r.PathValue("id"))
+		if o == nil || o.Status != "resting" {
+			return nil, notFound("order")
+		}
+		reduced := ex.cancel(o)
+		return map[string]any{"order": *o, "reduced_by": reduced}, nil
+	})
+}
+
+func notFound(what string) *apiError {
+	return &apiError{http.StatusNotFound, "not_found", what + " not found"}
+}
+
+// matches reports whether an optional query filter accepts value.
+func matches(q url.Values, key, value string) bool {
+	want := q.Get(key)
+	return want == "" || want == value
+}
+
+// paginate returns the page of items selected by the limit and cursor
+// parameters, and the cursor for the next page ("" on the last). Cursors
+// are offsets.
+func paginate[T any](items []T, q url.Values) ([]T, string) {
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	start, _ := strconv.Atoi(q.Get("cursor"))
+	start = min(max(start, 0), len(items))
+	end := min(start+limit, len(items))
+
+	page := items[start:end]
+	if page == nil {
+		page = []T{}
+	}
+	if end == len(items) {
+		return page, ""
+	}
+	return page, strconv.Itoa(end)
+}
+
+// order returns the order with id, or nil. Caller holds mu.
+func (ex *Exchange) order(id string) *kalshi.Order {
+	for _, o := range ex.orders {
+		if o.OrderID == id {
+			return o
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package kalshitest
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsOutbox bounds frames queued for one connection; a client that falls
+// this far behind is disconnected, as the real exchange would.
+const wsOutbox = 4096
+
+// Channels a subscription may name. Account channels stream every market
+// of the account; market channels stream the tickers listed, or every
+// market when none are.
+var wsChannels = map[string]bool{
+	"orderbook_delta":     true,
+	"ticker":              true,
+	"trade":               true,
+	"fill":                true,
+	"user_orders":         true,
+	"market_lifecycle_v2": true,
+}
+
+// wsMessageChannel maps pushed message types to the channel carrying them.
+var wsMessageChannel = map[string]string{
+	"orderbook_snapshot":  "orderbook_delta",
+	"orderbook_delta":     "orderbook_delta",
+	"ticker":              "ticker",
+	"trade":               "trade",
+	"fill":                "fill",
+	"user_order":          "user_orders",
+	"market_lifecycle_v2": "market_lifecycle_v2",
+}
+
+type wsFrame struct {
+	ID   int64  `json:"id,omitempty"`
+	Type string `json:"type"`
+	Sid  int64  `json:"sid,omitempty"`
+	Seq  int64  `json:"seq,omitempty"`
+	Msg  any    `json:"msg,omitempty"`
+}
+
+type wsCommand struct {
+	ID     int64  `json:"id"`
+	Cmd    string `json:"cmd"`
+	Params struct {
+		Channels      []string `json:"channels"`
+		MarketTickers []string `json:"market_tickers"`
+		Sids          []int64  `json:"sids"`
+		Action        string   `json:"action"`
+	} `json:"params"`
+}
+
+// wsConn is one client connection. Frames are queued under Exchange.mu, so
+// every client sees pushes in the order the exchange made them, and written
+// by a dedicated goroutine.
+type wsConn struct {
+	conn      *websocket.Conn
+	out       chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	subs map[int64]*wsSub // guarded by Exchange.mu
+}
+
+type wsSub struct {
+	channel string
+	tickers map[string]bool // nil streams every market
+	seq     int64           // last seq sent; orderbook_delta only
+}
+
+func (s *wsSub) streams(ticker string) bool {
+	return s.tickers == nil || s.tickers[ticker]
+}
+
+func (c *wsConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// send queues a frame. Caller holds Exchange.mu.
+func (c *wsConn) send(f wsFrame) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		slog.Error("kalshitest: encoding ws frame", "err", err)
+		return
+	}
+	select {
+	case c.out <- data:
+	default:
+		go c.close()
+	}
+}
+
+func (c *wsConn) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.out:
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+func (ex *Exchange) serveWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := ex.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &wsConn{
+		conn: conn,
+		out:  make(chan []byte, wsOutbox),
+		done: make(chan struct{}),
+		subs: make(map[int64]*wsSub),
+	}
+
+	ex.mu.Lock()
+	ex.conns[c] = true
+	ex.mu.Unlock()
+	go c.writeLoop()
+
+	defer func() {
+		ex.mu.Lock()
+		delete(ex.conns, c)
+		ex.mu.Unlock()
+		c.close()
+	}()
+
+	for {
+		var cmd wsCommand
+		if err := conn.ReadJSON(&cmd); err != nil {
+			return
+		}
+		ex.mu.Lock()
+		ex.handleCommand(c, cmd)
+		ex.mu.Unlock()
+	}
+}
+
+// handleCommand answers one client command. Caller holds mu.
+func (ex *Exchange) handleCommand(c *wsConn, cmd wsCommand) {
+	fail := func(code int, msg string) {
+		c.send(wsFrame{ID: cmd.ID, Type: "error", Msg: map[string]any{"code": code, "msg": msg}})
+	}
+	p := cmd.Params
+
+	switch cmd.Cmd {
+	case "subscribe":
+		if len(p.Channels) == 0 {
+			fail(2, "Params required")
+			return
+		}
+		for _, ch := range p.Channels {
+			if !wsChannels[ch] {
+				fail(8, "Unknown channel name")
+				return
+			}
+		}
+		for _, ch := range p.Channels {
+			ex.nextSid++
+			sub := &wsSub{channel: ch}
+			if len(p.MarketTickers) > 0 {
+				sub.tickers = make(map[string]bool)
+				for _, t := range p.MarketTickers {
+					sub.tickers[t] = true
+				}
+			}
+			c.subs[ex.nextSid] = sub
+			c.send(wsFrame{ID: cmd.ID, Type: "subscribed", Msg: map[string]any{"channel": ch, "sid": ex.nextSid}})
+			if ch == "orderbook_delta" {
+				ex.sendSnapshots(c, ex.nextSid, p.MarketTickers)
+			}
+		}
+
+	case "unsubscribe":
+		for _, sid := range p.Sids {
+			if c.subs[sid] == nil {
+				fail(6, "Subscription not found")
+				continue
+			}
+			delete(c.subs, sid)
+			c.send(wsFrame{ID: cmd.ID, Type: "unsubscribed", Sid: sid})
+		}
+
+	case "update_subscription":
+		if len(p.Sids) != 1 || c.subs[p.Sids[0]] == nil {
+			fail(6, "Subscription not found")
+			return
+		}
+		sid := p.Sids[0]
+		sub := c.subs[sid]
+		if sub.tickers == nil {
+			fail(13, "Subscription does not list markets")
+			return
+		}
+		switch p.Action {
+		case "add_markets":
+			for _, t := range p.MarketTickers {
+				sub.tickers[t] = true
+			}
+		case "delete_markets":
+			for _, t := range p.MarketTickers {
+				delete(sub.tickers, t)
+			}
+		default:
+			fail(12, "Unknown action")
+			return
+		}
+		ok := wsFrame{ID: cmd.ID, Type: "ok", Sid: sid, Msg: map[string]any{"market_tickers": p.MarketTickers}}
+		if sub.channel == "orderbook_delta" {
+			sub.seq++
+			ok.Seq = sub.seq
+		}
+		c.send(ok)
+		if sub.channel == "orderbook_delta" && p.Action == "add_markets" {
+			ex.sendSnapshots(c, sid, p.MarketTickers)
+		}
+
+	default:
+		fail(5, "Unknown command")
+	}
+}
+
+// sendSnapshots sends the current book of each known ticker on sid.
+// Caller holds mu.
+func (ex *Exchange) sendSnapshots(c *wsConn, sid int64, tickers []string) {
+	sub := c.subs[sid]
+	for _, t := range tickers {
+		m := ex.markets[t]
+		if m == nil {
+			continue
+		}
+		sub.seq++
+		c.send(wsFrame{Type: "orderbook_snapshot", Sid: sid, Seq: sub.seq, Msg: map[string]any{
+			"market_ticker": t,
+			"yes":           m.book.levels("yes"),
+			"no":            m.book.levels("no"),
+		}})
+	}
+}
+
+// push sends a message of type typ about ticker to every subscription on
+// its channel that streams ticker. Caller holds mu.
+func (ex *Exchange) push(typ, ticker string, msg any) {
+	channel := wsMessageChannel[typ]
+	for c := range ex.conns {
+		for sid, sub := range c.subs {
+			if sub.channel != channel || !sub.streams(ticker) {
+				continue
+			}
+			f := wsFrame{Type: typ, Sid: sid, Msg: msg}
+			if channel == "orderbook_delta" {
+				sub.seq++
+				f.Seq = sub.seq
+			}
+			c.send(f)
+		}
+	}
+}
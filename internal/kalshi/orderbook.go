@@ -0,0 +1,255 @@
+package kalshi
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"time"
+)
+
+// OrderbookState holds the current state of an orderbook for a ticker.
+// Resting bids are kept as quantities indexed by price (1–99c), with the
+// best price on each side tracked incrementally. It is a plain value:
+// copying it copies the whole book, which is how readers get snapshots.
+type OrderbookState struct {
+	Ticker     string
+	LastUpdate time.Time // when this orderbook was last updated (snapshot or delta)
+	Stale      bool      // invalidated after a sequence gap; awaiting a fresh snapshot
+
+	yes, no         [100]int // resting bid quantity by price; index 0 unused
+	bestYes, bestNo int      // highest price with quantity, 0 if the side is empty
+}
+
+type PriceLevel struct {
+	Price    int
+	Quantity int
+}
+
+// NewOrderbook builds a book from YES and NO bid levels in any order.
+func NewOrderbook(ticker string, yes, no []PriceLevel) (*OrderbookState, error) {
+	ob := &OrderbookState{Ticker: ticker}
+	for _, l := range yes {
+		if err := ob.add("yes", l.Price, l.Quantity); err != nil {
+			return nil, err
+		}
+	}
+	for _, l := range no {
+		if err := ob.add("no", l.Price, l.Quantity); err != nil {
+			return nil, err
+		}
+	}
+	return ob, nil
+}
+
+func (ob *OrderbookState) side(side string) (*[100]int, *int) {
+	if side == "yes" {
+		return &ob.yes, &ob.bestYes
+	}
+	return &ob.no, &ob.bestNo
+}
+
+// add changes the resting quantity at price by delta. It fails, leaving the
+// book unchanged, if the price is out of range or the quantity would go
+// negative.
+func (ob *OrderbookState) add(side string, price, delta int) error {
+	if price < 1 || price > 99 {
+		return fmt.Errorf("%s %s@%d: price out of range", ob.Ticker, side, price)
+	}
+	qty, best := ob.side(side)
+	n := qty[price] + delta
+	if n < 0 {
+		if qty[price] == 0 {
+			return fmt.Errorf("%s %s@%d: delta %d for unknown level", ob.Ticker, side, price, delta)
+		}
+		return fmt.Errorf("%s %s@%d: delta %d exceeds resting %d", ob.Ticker, side, price, delta, qty[price])
+	}
+	qty[price] = n
+
+	switch {
+	case n > 0 && price > *best:
+		*best = price
+	case n == 0 && price == *best:
+		p := price - 1
+		for p > 0 && qty[p] == 0 {
+			p--
+		}
+		*best = p
+	}
+	return nil
+}
+
+// Quantity returns the resting bid quantity for side at price.
+func (ob *OrderbookState) Quantity(side string, price int) int {
+	if price < 1 || price > 99 {
+		return 0
+	}
+	qty, _ := ob.side(side)
+	return qty[price]
+}
+
+// Bids yields the resting bids on side, best (highest price) first.
+func (ob *OrderbookState) Bids(side string) iter.Seq[PriceLevel] {
+	qty, best := ob.side(side)
+	return func(yield func(PriceLevel) bool) {
+		for p := *best; p > 0; p-- {
+			if qty[p] > 0 && !yield(PriceLevel{Price: p, Quantity: qty[p]}) {
+				return
+			}
+		}
+	}
+}
+
+// Asks yields the levels a buyer of side can lift, best (lowest price)
+// first, in the buyer's terms. Buying YES lifts NO bids at 100 minus their
+// price, and vice versa. Nothing is allocated.
+func (ob *OrderbookState) Asks(side string) iter.Seq[PriceLevel] {
+	opposite := "yes"
+	if side == "yes" {
+		opposite = "no"
+	}
+	return func(yield func(PriceLevel) bool) {
+		for l := range ob.Bids(opposite) {
+			if !yield(PriceLevel{Price: 100 - l.Price, Quantity: l.Quantity}) {
+				return
+			}
+		}
+	}
+}
+
+// Levels returns the number of YES and NO price levels with quantity.
+func (ob *OrderbookState) Levels() (yes, no int) {
+	for p := 1; p <= 99; p++ {
+		if ob.yes[p] > 0 {
+			yes++
+		}
+		if ob.no[p] > 0 {
+			no++
+		}
+	}
+	return yes, no
+}
+
+func (ob *OrderbookState) BestYesBid() int {
+	return ob.bestYes
+}
+
+func (ob *OrderbookState) BestYesAsk() int {
+	return 100 - ob.bestNo
+}
+
+// AskDepth returns ask-side depth for buying a given side, sorted best
+// (lowest ask price) first. Use Asks to walk the book without allocating.
+func (ob *OrderbookState) AskDepth(side string) []PriceLevel {
+	yes, no := ob.Levels()
+	n := yes
+	if side == "yes" {
+		n = no
+	}
+	levels := make([]PriceLevel, 0, n)
+	for l := range ob.Asks(side) {
+		levels = append(levels, l)
+	}
+	return levels
+}
+
+// Spread returns the YES ask minus the YES bid in cents. A one-sided book
+// has an empty side priced at 0 (bid) or 100 (ask).
+func (ob *OrderbookState) Spread() int {
+	return ob.BestYesAsk() - ob.BestYesBid()
+}
+
+// Microprice returns the size-weighted YES mid: the bid weighted by ask
+// size and the ask by bid size, so it leans toward the thinner side. It
+// returns 0 unless both sides have liquidity.
+func (ob *OrderbookState) Microprice() float64 {
+	if ob.bestYes == 0 || ob.bestNo == 0 {
+		return 0
+	}
+	bid, bidQty := float64(ob.BestYesBid()), float64(ob.yes[ob.bestYes])
+	ask, askQty := float64(ob.BestYesAsk()), float64(ob.no[ob.bestNo])
+	return (bid*askQty + ask*bidQty) / (bidQty + askQty)
+}
+
+// Imbalance compares YES bid depth with YES ask depth over the top levels
+// of each side: (bid - ask) / (bid + ask), in [-1, 1]. Positive means more
+// buying interest in YES. levels <= 0 uses the whole book.
+func (ob *OrderbookState) Imbalance(levels int) float64 {
+	sum := func(side string) int {
+		n, i := 0, 0
+		for l := range ob.Bids(side) {
+			if levels > 0 && i == levels {
+				break
+			}
+			n += l.Quantity
+			i++
+		}
+		return n
+	}
+	bid, ask := sum("yes"), sum("no")
+	if bid+ask == 0 {
+		return 0
+	}
+	return float64(bid-ask) / float64(bid+ask)
+}
+
+// CumulativeSize returns how many contracts of side can be bought at a
+// price of limit or better.
+func (ob *OrderbookState) CumulativeSize(side string, limit int) int {
+	n := 0
+	for l := range ob.Asks(side) {
+		if l.Price > limit {
+			break
+		}
+		n += l.Quantity
+	}
+	return n
+}
+
+// Sweep is the outcome of buying contracts by walking the asks.
+type Sweep struct {
+	Filled     int // contracts available, at most the number requested
+	Cost       int // cents paid before fees
+	Fee        int // taker fee in cents
+	WorstPrice int // price of the last level touched
+}
+
+// Total returns the cents paid including fees.
+func (s Sweep) Total() int {
+	return s.Cost + s.Fee
+}
+
+// AvgPrice returns the volume-weighted price per contract before fees.
+func (s Sweep) AvgPrice() float64 {
+	if s.Filled == 0 {
+		return 0
+	}
+	return float64(s.Cost) / float64(s.Filled)
+}
+
+// SweepCost prices a taker buy of contracts on side against the current
+// book. The fee treats the sweep as one order: the per-level fee terms are
+// summed and rounded up once. Filled is short of contracts when the book
+// is too thin.
+func (ob *OrderbookState) SweepCost(side string, contracts int) Sweep {
+	var s Sweep
+	var rawFee float64
+	for l := range ob.Asks(side) {
+		if s.Filled >= contracts {
+			break
+		}
+		n := min(l.Quantity, contracts-s.Filled)
+		s.Filled += n
+		s.Cost += n * l.Price
+		s.WorstPrice = l.Price
+		rawFee += takerFeeRaw(n, l.Price)
+	}
+	s.Fee = int(math.Ceil(rawFee))
+	return s
+}
+
+// VWAP returns the average price per contract to buy contracts of side,
+// and how many could be filled.
+func (ob *OrderbookState) VWAP(side string, contracts int) (float64, int) {
+	s := ob.SweepCost(side, contracts)
+	return s.AvgPrice(), s.Filled
+}
@@ -0,0 +1,169 @@
+package kalshi
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// sliceBook is the previous OrderbookState representation, kept here so
+// the benchmarks can compare against it: levels held in slices sorted
+// best first, updated by linear scan and insertion.
+type sliceBook struct {
+	Yes, No []PriceLevel
+}
+
+func (ob *sliceBook) applyDelta(side string, price, delta int) {
+	levels := &ob.No
+	if side == "yes" {
+		levels = &ob.Yes
+	}
+	for i, l := range *levels {
+		if l.Price == price {
+			if n := l.Quantity + delta; n <= 0 {
+				*levels = append((*levels)[:i], (*levels)[i+1:]...)
+			} else {
+				(*levels)[i].Quantity = n
+			}
+			return
+		}
+	}
+	if delta > 0 {
+		*levels = append(*levels, PriceLevel{Price: price, Quantity: delta})
+		for i := len(*levels) - 1; i > 0; i-- {
+			if (*levels)[i].Price > (*levels)[i-1].Price {
+				(*levels)[i], (*levels)[i-1] = (*levels)[i-1], (*levels)[i]
+			}
+		}
+	}
+}
+
+func (ob *sliceBook) AskDepth(side string) []PriceLevel {
+	source := ob.Yes
+	if side == "yes" {
+		source = ob.No
+	}
+	levels := make([]PriceLevel, 0, len(source))
+	for _, l := range source {
+		levels = append(levels, PriceLevel{Price: 100 - l.Price, Quantity: l.Quantity})
+	}
+	return levels
+}
+
+func (ob *sliceBook) snapshot() *sliceBook {
+	return &sliceBook{Yes: slices.Clone(ob.Yes), No: slices.Clone(ob.No)}
+}
+
+// benchDelta is one level change that keeps quantities non-negative.
+type benchDelta struct {
+	side         string
+	price, delta int
+}
+
+// benchFeed builds a realistic book (about 40 levels a side) and a stream
+// of deltas against it that adds and removes whole levels as well as
+// resizing existing ones.
+func benchFeed(n int) (yes, no []PriceLevel, deltas []benchDelta) {
+	r := rand.New(rand.NewPCG(1, 2))
+	var qty [2][100]int
+	for p := 10; p <= 49; p++ {
+		qty[0][p] = 1 + r.IntN(500)
+		qty[1][p] = 1 + r.IntN(500)
+	}
+	for p := 99; p >= 1; p-- {
+		if qty[0][p] > 0 {
+			yes = append(yes, PriceLevel{p, qty[0][p]})
+		}
+		if qty[1][p] > 0 {
+			no = append(no, PriceLevel{p, qty[1][p]})
+		}
+	}
+
+	for range n {
+		s, side := r.IntN(2), "yes"
+		if s == 1 {
+			side = "no"
+		}
+		p := 5 + r.IntN(50)
+		d := r.IntN(200) - 100
+		if r.IntN(4) == 0 {
+			d = -qty[s][p] // clear the level
+		}
+		if qty[s][p]+d < 0 {
+			d = -qty[s][p]
+		}
+		if d == 0 {
+			d = 1
+		}
+		qty[s][p] += d
+		deltas = append(deltas, benchDelta{side, p, d})
+	}
+	return yes, no, deltas
+}
+
+func BenchmarkApplyDelta(b *testing.B) {
+	yes, no, deltas := benchFeed(4096)
+
+	b.Run("array", func(b *testing.B) {
+		for b.Loop() {
+			ob := mustBook(yes, no)
+			for _, d := range deltas {
+				if err := ob.add(d.side, d.price, d.delta); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		for b.Loop() {
+			ob := &sliceBook{Yes: slices.Clone(yes), No: slices.Clone(no)}
+			for _, d := range deltas {
+				ob.applyDelta(d.side, d.price, d.delta)
+			}
+		}
+	})
+}
+
+func BenchmarkAskDepth(b *testing.B) {
+	yes, no, _ := benchFeed(0)
+
+	b.Run("array", func(b *testing.B) {
+		ob := mustBook(yes, no)
+		for b.Loop() {
+			ob.AskDepth("yes")
+		}
+	})
+	b.Run("array-iter", func(b *testing.B) {
+		ob := mustBook(yes, no)
+		for b.Loop() {
+			n := 0
+			for l := range ob.Asks("yes") {
+				n += l.Quantity
+			}
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		ob := &sliceBook{Yes: yes, No: no}
+		for b.Loop() {
+			ob.AskDepth("yes")
+		}
+	})
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	yes, no, _ := benchFeed(0)
+
+	b.Run("array", func(b *testing.B) {
+		ob := mustBook(yes, no)
+		for b.Loop() {
+			snap := *ob
+			_ = snap.BestYesBid()
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		ob := &sliceBook{Yes: yes, No: no}
+		for b.Loop() {
+			ob.snapshot()
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package kalshi
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// testBook: YES bids 40x10, 38x20; NO bids 55x5, 50x15
+// => YES asks 45x5, 50x15; NO asks 60x10, 62x20.
+func testBook() *OrderbookState {
+	return mustBook([]PriceLevel{{40, 10}, {38, 20}}, []PriceLevel{{55, 5}, {50, 15}})
+}
+
+func mustBook(yes, no []PriceLevel) *OrderbookState {
+	ob, err := NewOrderbook("T", yes, no)
+	if err != nil {
+		panic(err)
+	}
+	return ob
+}
+
+func TestOrderbookBestTracking(t *testing.T) {
+	ob := testBook()
+	steps := []struct {
+		price, delta int
+		wantBest     int
+	}{
+		{42, 3, 42},  // new best
+		{39, 4, 42},  // behind best
+		{42, -3, 40}, // best emptied, falls back
+		{40, -10, 39},
+		{39, -4, 38},
+		{38, -20, 0}, // side empty
+		{7, 1, 7},
+	}
+	for _, st := range steps {
+		if err := ob.add("yes", st.price, st.delta); err != nil {
+			t.Fatalf("add(%d, %d): %v", st.price, st.delta, err)
+		}
+		if got := ob.BestYesBid(); got != st.wantBest {
+			t.Errorf("after add(%d, %d): BestYesBid = %d, want %d", st.price, st.delta, got, st.wantBest)
+		}
+	}
+
+	for _, bad := range []struct{ price, delta int }{{0, 1}, {100, 1}, {7, -2}, {8, -1}} {
+		if err := ob.add("yes", bad.price, bad.delta); err == nil {
+			t.Errorf("add(%d, %d) succeeded, want error", bad.price, bad.delta)
+		}
+	}
+	if got := ob.Quantity("yes", 7); got != 1 {
+		t.Errorf("failed adds changed the book: qty@7 = %d, want 1", got)
+	}
+}
+
+func TestOrderbookLevels(t *testing.T) {
+	ob := testBook()
+	if got := slices.Collect(ob.Bids("yes")); !slices.Equal(got, []PriceLevel{{40, 10}, {38, 20}}) {
+		t.Errorf("Bids(yes) = %v", got)
+	}
+	if got := ob.AskDepth("yes"); !slices.Equal(got, []PriceLevel{{45, 5}, {50, 15}}) {
+		t.Errorf("AskDepth(yes) = %v", got)
+	}
+	if got := ob.AskDepth("no"); !slices.Equal(got, []PriceLevel{{60, 10}, {62, 20}}) {
+		t.Errorf("AskDepth(no) = %v", got)
+	}
+
+	// A copy is independent of the original.
+	snap := *ob
+	ob.add("yes", 40, -10)
+	if snap.BestYesBid() != 40 || snap.Quantity("yes", 40) != 10 {
+		t.Errorf("copy changed with original: best %d, qty %d", snap.BestYesBid(), snap.Quantity("yes", 40))
+	}
+}
+
+func TestTopOfBookAnalytics(t *testing.T) {
+	ob := testBook()
+	if got := ob.Spread(); got != 5 {
+		t.Errorf("Spread = %d, want 5", got)
+	}
+	// (40*5 + 45*10) / 15
+	if got := ob.Microprice(); math.Abs(got-43.333) > 0.001 {
+		t.Errorf("Microprice = %.3f, want 43.333", got)
+	}
+	// top level: (10 - 5) / 15; whole book: (30 - 20) / 50
+	if got := ob.Imbalance(1); math.Abs(got-1.0/3) > 1e-9 {
+		t.Errorf("Imbalance(1) = %.3f, want 0.333", got)
+	}
+	if got := ob.Imbalance(0); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("Imbalance(0) = %.3f, want 0.2", got)
+	}
+	if got := mustBook([]PriceLevel{{40, 1}}, nil).Microprice(); got != 0 {
+		t.Errorf("one-sided Microprice = %v, want 0", got)
+	}
+}
+
+func TestCumulativeSize(t *testing.T) {
+	ob := testBook()
+	tests := []struct {
+		side  string
+		limit int
+		want  int
+	}{
+		{"yes", 44, 0},
+		{"yes", 45, 5},
+		{"yes", 99, 20},
+		{"no", 60, 10},
+		{"no", 62, 30},
+	}
+	for _, tt := range tests {
+		if got := ob.CumulativeSize(tt.side, tt.limit); got != tt.want {
+			t.Errorf("CumulativeSize(%s, %d) = %d, want %d", tt.side, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestSweepCost(t *testing.T) {
+	ob := testBook()
+
+	s := ob.SweepCost("yes", 10)
+	// 5 @ 45 + 5 @ 50
+	if s.Filled != 10 || s.Cost != 475 || s.WorstPrice != 50 {
+		t.Errorf("Sweep = %+v, want 10 filled, cost 475, worst 50", s)
+	}
+	// 0.07*5*.45*.55*100 + 0.07*5*.5*.5*100 = 8.6625 + 8.75 -> 18
+	if s.Fee != 18 || s.Total() != 493 {
+		t.Errorf("Fee = %d, Total = %d, want 18, 493", s.Fee, s.Total())
+	}
+
+	avg, filled := ob.VWAP("yes", 100)
+	if filled != 20 || math.Abs(avg-48.75) > 1e-9 {
+		t.Errorf("VWAP = %.2f over %d, want 48.75 over 20", avg, filled)
+	}
+
+	if s := ob.SweepCost("no", 1); s.Cost != 60 || s.Fee != TakerFee(1, 60) {
+		t.Errorf("single-level sweep = %+v", s)
+	}
+}
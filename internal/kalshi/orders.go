@@ -0,0 +1,158 @@
+package kalshi
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/url"
+)
+
+// ErrOrderNotFound is returned when no order matches a lookup.
+var ErrOrderNotFound = errors.New("order not found")
+
+// GetOrder returns the current state of a single order.
+func (c *Client) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	var result struct {
+		Order Order `json:"order"`
+	}
+	if err := c.get(ctx, "/portfolio/orders/"+orderID, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Order, nil
+}
+
+// GetOrders returns every order matching params (ticker, event_ticker,
+// status such as "resting").
+func (c *Client) GetOrders(ctx context.Context, params url.Values) ([]Order, error) {
+	return collect(c.OrdersIter(ctx, params))
+}
+
+// OrdersIter yields pages of /portfolio/orders.
+func (c *Client) OrdersIter(ctx context.Context, params url.Values) iter.Seq2[[]Order, error] {
+	return pages(ctx, func(ctx context.Context, cursor string) ([]Order, string, error) {
+		var result struct {
+			Orders []Order `json:"orders"`
+			Cursor string  `json:"cursor"`
+		}
+		if err := c.get(ctx, "/portfolio/orders", pageParams(params, cursor), &result); err != nil {
+			return nil, "", err
+		}
+		return result.Orders, result.Cursor, nil
+	})
+}
+
+// GetOrderByClientID finds the order on ticker placed with clientOrderID,
+// in any status. Returns ErrOrderNotFound if the exchange has no such order.
+func (c *Client) GetOrderByClientID(ctx context.Context, ticker, clientOrderID string) (*Order, error) {
+	params := url.Values{}
+	params.Set("ticker", ticker)
+
+	for orders, err := range c.OrdersIter(ctx, params) {
+		if err != nil {
+			return nil, err
+		}
+		for i := range orders {
+			if orders[i].ClientOrderID == clientOrderID {
+				return &orders[i], nil
+			}
+		}
+	}
+	return nil, ErrOrderNotFound
+}
+
+// CancelOrder cancels the resting remainder of an order and returns its
+// final state, including any contracts filled before the cancel.
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (*Order, error) {
+	var result struct {
+		Order     Order `json:"order"`
+		ReducedBy int   `json:"reduced_by"`
+	}
+	if err := c.delete(ctx, "/portfolio/orders/"+orderID, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Order, nil
+}
+
+// AmendOrderRequest changes the price and/or total count of a resting
+// order. Ticker, Side and Action must match the original order.
+type AmendOrderRequest struct {
+	Ticker               string `json:"ticker"`
+	Side                 string `json:"side"`
+	Action               string `json:"action"`
+	ClientOrderID        string `json:"client_order_id"`
+	UpdatedClientOrderID string `json:"updated_client_order_id"`
+	Count                int    `json:"count"`
+	YesPrice             int    `json:"yes_price,omitempty"`
+	NoPrice              int    `json:"no_price,omitempty"`
+}
+
+// AmendOrder re-prices or re-sizes a resting order, returning the order
+// after the amendment.
+func (c *Client) AmendOrder(ctx context.Context, orderID string, req AmendOrderRequest) (*Order, error) {
+	var result struct {
+		OldOrder Order `json:"old_order"`
+		Order    Order `json:"order"`
+	}
+	if err := c.post(ctx, "/portfolio/orders/"+orderID+"/amend", req, &result); err != nil {
+		return nil, err
+	}
+	return &result.Order, nil
+}
+
+// DecreaseOrder shrinks a resting order. Exactly one of reduceBy (contracts
+// to remove) or reduceTo (remaining count to leave) should be non-zero.
+func (c *Client) DecreaseOrder(ctx context.Context, orderID string, reduceBy, reduceTo int) (*Order, error) {
+	body := struct {
+		ReduceBy int `json:"reduce_by,omitempty"`
+		ReduceTo int `json:"reduce_to,omitempty"`
+	}{reduceBy, reduceTo}
+
+	var result struct {
+		Order Order `json:"order"`
+	}
+	if err := c.post(ctx, "/portfolio/orders/"+orderID+"/decrease", body, &result); err != nil {
+		return nil, err
+	}
+	return &result.Order, nil
+}
+
+// BatchOrderResult is one entry of a batch create or cancel response. Error
+// is set when that individual order failed; the rest of the batch still ran.
+type BatchOrderResult struct {
+	ClientOrderID string    `json:"client_order_id"`
+	OrderID       string    `json:"order_id"`
+	ReducedBy     int       `json:"reduced_by"`
+	Order         *Order    `json:"order"`
+	Error         *APIError `json:"error"`
+}
+
+// BatchCreateOrders submits several orders in one request. Results are in
+// the same order as reqs.
+func (c *Client) BatchCreateOrders(ctx context.Context, reqs []OrderRequest) ([]BatchOrderResult, error) {
+	body := struct {
+		Orders []OrderRequest `json:"orders"`
+	}{reqs}
+
+	var result struct {
+		Orders []BatchOrderResult `json:"orders"`
+	}
+	if err := c.post(ctx, "/portfolio/orders/batched", body, &result); err != nil {
+		return nil, err
+	}
+	return result.Orders, nil
+}
+
+// BatchCancelOrders cancels several orders in one request.
+func (c *Client) BatchCancelOrders(ctx context.Context, orderIDs []string) ([]BatchOrderResult, error) {
+	body := struct {
+		IDs []string `json:"ids"`
+	}{orderIDs}
+
+	var result struct {
+		Orders []BatchOrderResult `json:"orders"`
+	}
+	if err := c.delete(ctx, "/portfolio/orders/batched", body, &result); err != nil {
+		return nil, err
+	}
+	return result.Orders, nil
+}
@@ -0,0 +1,82 @@
+package kalshi
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+)
+
+// pageLimit is the page size requested from cursor-paginated list endpoints.
+const pageLimit = 200
+
+// fetchPage retrieves one page of a list endpoint starting at cursor and
+// returns its rows and the cursor for the next page ("" when exhausted).
+type fetchPage[T any] func(ctx context.Context, cursor string) ([]T, string, error)
+
+// pages yields successive pages from fetch until the cursor runs out, the
+// context is cancelled, or the caller stops iterating. An error ends the
+// sequence after being yielded once.
+func pages[T any](ctx context.Context, fetch fetchPage[T]) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		cursor := ""
+		seen := make(map[string]bool)
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			rows, next, err := fetch(ctx, cursor)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(rows, nil) {
+				return
+			}
+
+			if next == "" {
+				return
+			}
+			// Guard against a server handing back a cursor we've already
+			// followed, which would otherwise loop forever.
+			if seen[next] {
+				yield(nil, fmt.Errorf("pagination cursor %q repeated", next))
+				return
+			}
+			seen[next] = true
+			cursor = next
+		}
+	}
+}
+
+// collect drains a page sequence into a single slice.
+func collect[T any](seq iter.Seq2[[]T, error]) ([]T, error) {
+	var all []T
+	for rows, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, rows...)
+	}
+	return all, nil
+}
+
+// pageParams copies params and sets the page limit and cursor, so callers'
+// values are never mutated between pages.
+func pageParams(params url.Values, cursor string) url.Values {
+	p := url.Values{}
+	for k, v := range params {
+		p[k] = append([]string(nil), v...)
+	}
+	if p.Get("limit") == "" {
+		p.Set("limit", fmt.Sprintf("%d", pageLimit))
+	}
+	if cursor != "" {
+		p.Set("cursor", cursor)
+	} else {
+		p.Del("cursor")
+	}
+	return p
+}
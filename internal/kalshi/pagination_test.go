@@ -0,0 +1,96 @@
+package kalshi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestPagesFollowsCursor(t *testing.T) {
+	book := map[string]struct {
+		rows []int
+		next string
+	}{
+		"":   {rows: []int{1, 2}, next: "c1"},
+		"c1": {rows: []int{3}, next: "c2"},
+		"c2": {rows: []int{4, 5}, next: ""},
+	}
+
+	var cursors []string
+	fetch := func(ctx context.Context, cursor string) ([]int, string, error) {
+		cursors = append(cursors, cursor)
+		p := book[cursor]
+		return p.rows, p.next, nil
+	}
+
+	got, err := collect(pages(context.Background(), fetch))
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	want := []int{1, 2, 3, 4, 5}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if len(cursors) != 3 || cursors[1] != "c1" || cursors[2] != "c2" {
+		t.Errorf("cursors followed = %q", cursors)
+	}
+}
+
+func TestPagesStopsOnError(t *testing.T) {
+	boom := errors.New("boom")
+	calls := 0
+	fetch := func(ctx context.Context, cursor string) ([]int, string, error) {
+		calls++
+		if cursor == "c1" {
+			return nil, "", boom
+		}
+		return []int{1}, "c1", nil
+	}
+
+	if _, err := collect(pages(context.Background(), fetch)); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestPagesRepeatedCursor(t *testing.T) {
+	fetch := func(ctx context.Context, cursor string) ([]int, string, error) {
+		return []int{1}, "same", nil
+	}
+	if _, err := collect(pages(context.Background(), fetch)); err == nil {
+		t.Fatal("expected error on repeated cursor")
+	}
+}
+
+func TestPagesCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fetch := func(ctx context.Context, cursor string) ([]int, string, error) {
+		t.Fatal("fetch called after cancel")
+		return nil, "", nil
+	}
+	if _, err := collect(pages(ctx, fetch)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestPageParamsDoesNotMutate(t *testing.T) {
+	params := url.Values{}
+	params.Set("ticker", "KXBTC15M-X")
+
+	p := pageParams(params, "abc")
+	if p.Get("cursor") != "abc" || p.Get("limit") != "200" || p.Get("ticker") != "KXBTC15M-X" {
+		t.Errorf("pageParams = %v", p)
+	}
+	if params.Get("cursor") != "" || params.Get("limit") != "" {
+		t.Errorf("caller params mutated: %v", params)
+	}
+}
@@ -0,0 +1,139 @@
+package kalshi
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// recordedFrame is one line of a recording: a raw WS frame and when it was
+// received, or a marker that a new connection was opened.
+type recordedFrame struct {
+	TS      int64           `json:"ts"` // unix nanoseconds
+	Frame   json.RawMessage `json:"frame,omitempty"`
+	Connect bool            `json:"connect,omitempty"` // sids and seqs restart after this
+}
+
+// Recorder writes every raw WS frame with its receive time to gzipped JSONL
+// files in a directory, starting a new file every rotateEvery. Files are
+// named by their first frame's time, so sorting names sorts the stream.
+type Recorder struct {
+	dir         string
+	rotateEvery time.Duration
+
+	mu        sync.Mutex
+	file      *os.File
+	gz        *gzip.Writer
+	buf       *bufio.Writer
+	fileStart time.Time
+	errLogged bool
+	closed    bool
+}
+
+// NewRecorder creates dir if needed and returns a Recorder writing into it.
+func NewRecorder(dir string, rotateEvery time.Duration) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating recording dir: %w", err)
+	}
+	return &Recorder{dir: dir, rotateEvery: rotateEvery}, nil
+}
+
+// SetRecorder makes ws record every frame it receives. Call before Run.
+func (ws *WSClient) SetRecorder(r *Recorder) {
+	ws.recorder = r
+}
+
+// Record appends one frame. Failures are logged once and otherwise
+// ignored; recording must never disturb trading.
+func (r *Recorder) Record(at time.Time, frame []byte) {
+	r.write(at, recordedFrame{TS: at.UnixNano(), Frame: frame})
+}
+
+// RecordConnect marks that a new connection opened at at. Kalshi numbers
+// sids and seqs per connection, so replay resets them here as the live
+// client does.
+func (r *Recorder) RecordConnect(at time.Time) {
+	r.write(at, recordedFrame{TS: at.UnixNano(), Connect: true})
+}
+
+// write appends rec, rotating files as needed.
+func (r *Recorder) write(at time.Time, rec recordedFrame) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		slog.Debug("ws recorder: frame is not JSON, skipped", "err", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	if r.buf == nil || at.Sub(r.fileStart) >= r.rotateEvery {
+		if err := r.rotate(at); err != nil {
+			r.logErr(err)
+			return
+		}
+	}
+	r.buf.Write(line)
+	if err := r.buf.WriteByte('\n'); err != nil {
+		r.logErr(err)
+	}
+}
+
+// rotate closes the current file and opens one named for at. Caller holds mu.
+func (r *Recorder) rotate(at time.Time) error {
+	if err := r.closeFile(); err != nil {
+		r.logErr(err)
+	}
+
+	name := filepath.Join(r.dir, "kalshi-ws-"+at.UTC().Format("20060102T150405.000Z")+".jsonl.gz")
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("ws recorder: %w", err)
+	}
+	r.file = f
+	r.gz = gzip.NewWriter(f)
+	r.buf = bufio.NewWriterSize(r.gz, 64<<10)
+	r.fileStart = at
+	slog.Info("ws recorder: new file", "path", name)
+	return nil
+}
+
+// closeFile flushes and closes the current file, if any. Caller holds mu.
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.buf.Flush()
+	if cerr := r.gz.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file, r.gz, r.buf = nil, nil, nil
+	return err
+}
+
+func (r *Recorder) logErr(err error) {
+	if !r.errLogged {
+		slog.Error("ws recorder failed", "err", err)
+		r.errLogged = true
+	}
+}
+
+// Close flushes and closes the current file. Later frames are dropped.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return r.closeFile()
+}
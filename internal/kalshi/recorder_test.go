@@ -0,0 +1,97 @@
+package kalshi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, time.Minute)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+
+	t0 := time.Date(2026, 2, 10, 17, 26, 0, 0, time.UTC)
+	frames := []string{
+		testSnapshot,
+		`{"type":"orderbook_delta","sid":1,"seq":2,"msg":{"market_ticker":"T1","price":42,"delta":3,"side":"yes"}}`,
+		// Past the rotation interval: lands in a second file
+		`{"type":"orderbook_delta","sid":1,"seq":3,"msg":{"market_ticker":"T1","price":44,"delta":1,"side":"yes"}}`,
+	}
+	times := []time.Time{t0, t0.Add(time.Second), t0.Add(2 * time.Minute)}
+	for i, f := range frames {
+		rec.Record(times[i], []byte(f))
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	paths, err := RecordingFiles(dir)
+	if err != nil || len(paths) != 2 {
+		t.Fatalf("RecordingFiles = %v, %v; want 2 files", paths, err)
+	}
+
+	// Full replay reproduces the final book with the recorded timestamps
+	ws := newTestWSClient(t)
+	var seen int
+	r := &Replayer{OnFrame: func(time.Time) { seen++ }}
+	if err := r.Replay(context.Background(), ws, paths); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	ob := ws.GetOrderbook("T1")
+	if seen != 3 || ob == nil || ob.BestYesBid() != 44 {
+		t.Fatalf("after full replay: frames=%d book=%+v", seen, ob)
+	}
+	if !ob.LastUpdate.Equal(times[2]) || !r.Now().Equal(times[2]) {
+		t.Errorf("LastUpdate = %s, Now = %s, want %s", ob.LastUpdate, r.Now(), times[2])
+	}
+
+	// Replaying to an earlier instant rebuilds the book as it was then
+	ws = newTestWSClient(t)
+	r = &Replayer{Until: t0.Add(30 * time.Second)}
+	if err := r.Replay(context.Background(), ws, paths); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if ob := ws.GetOrderbook("T1"); ob == nil || ob.BestYesBid() != 42 {
+		t.Fatalf("book at t0+30s = %+v, want best bid 42", ob)
+	}
+}
+
+func TestReplayAcrossReconnect(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+
+	t0 := time.Date(2026, 2, 10, 17, 26, 0, 0, time.UTC)
+	rec.Record(t0, []byte(testSnapshot))
+	rec.Record(t0.Add(time.Second), []byte(`{"type":"orderbook_delta","sid":1,"seq":2,"msg":{"market_ticker":"T1","price":42,"delta":3,"side":"yes"}}`))
+	// The second connection reuses sid 1 and restarts its seqs
+	rec.RecordConnect(t0.Add(time.Minute))
+	rec.Record(t0.Add(61*time.Second), []byte(`{"type":"orderbook_snapshot","sid":1,"seq":1,"msg":{"market_ticker":"T1","yes":[[45,10]],"no":[[50,5]]}}`))
+	rec.Record(t0.Add(62*time.Second), []byte(`{"type":"orderbook_delta","sid":1,"seq":2,"msg":{"market_ticker":"T1","price":46,"delta":1,"side":"yes"}}`))
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	paths, err := RecordingFiles(dir)
+	if err != nil {
+		t.Fatalf("RecordingFiles: %v", err)
+	}
+
+	ws := newTestWSClient(t)
+	var seen int
+	r := &Replayer{OnFrame: func(time.Time) { seen++ }}
+	if err := r.Replay(context.Background(), ws, paths); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	ob := ws.GetOrderbook("T1")
+	if seen != 4 || ob == nil || ob.BestYesBid() != 46 {
+		t.Fatalf("after replay: frames=%d book=%+v, want best bid 46", seen, ob)
+	}
+	if ws.SeqGaps() != 0 {
+		t.Errorf("SeqGaps = %d, want 0 across the reconnect", ws.SeqGaps())
+	}
+}
@@ -0,0 +1,134 @@
+package kalshi
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxReplayLine bounds one recorded frame; full orderbook snapshots are the
+// largest and stay well under this.
+const maxReplayLine = 16 << 20
+
+// Replayer feeds recorded frames back through a WSClient's message handler,
+// so its books, channel state and events are rebuilt exactly as they were.
+// The client's clock follows the recording: book LastUpdate and event times
+// are the original receive times.
+type Replayer struct {
+	// Speed scales playback: 1 is real time, 10 is ten times faster, and 0
+	// replays as fast as possible.
+	Speed float64
+
+	// Until stops playback after the last frame received at or before it.
+	// Zero replays everything.
+	Until time.Time
+
+	// OnFrame, if set, runs after each frame is handled, e.g. to drive a
+	// strategy tick at the replayed instant.
+	OnFrame func(at time.Time)
+
+	clock atomic.Int64 // unix nanoseconds of the last replayed frame
+}
+
+// Now returns the receive time of the most recently replayed frame.
+func (r *Replayer) Now() time.Time {
+	return time.Unix(0, r.clock.Load())
+}
+
+// Replay plays the recording files at paths, in order, into ws. Use
+// RecordingFiles to list a recorder's directory.
+func (r *Replayer) Replay(ctx context.Context, ws *WSClient, paths []string) error {
+	ws.now = r.Now
+
+	var prev time.Time
+	for _, path := range paths {
+		done, err := r.replayFile(ctx, ws, path, &prev)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if done {
+			return nil
+		}
+	}
+	return nil
+}
+
+// replayFile plays one file. It reports done once Until has passed.
+func (r *Replayer) replayFile(ctx context.Context, ws *WSClient, path string, prev *time.Time) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var src io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return false, err
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	sc := bufio.NewScanner(src)
+	sc.Buffer(make([]byte, 64<<10), maxReplayLine)
+	for sc.Scan() {
+		var rec recordedFrame
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return false, fmt.Errorf("bad record: %w", err)
+		}
+		at := time.Unix(0, rec.TS)
+		if !r.Until.IsZero() && at.After(r.Until) {
+			return true, nil
+		}
+
+		if r.Speed > 0 && !prev.IsZero() && at.After(*prev) {
+			if err := sleepCtx(ctx, time.Duration(float64(at.Sub(*prev))/r.Speed)); err != nil {
+				return false, err
+			}
+		} else if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		*prev = at
+
+		r.clock.Store(rec.TS)
+		if rec.Connect {
+			// A new connection: reset what connect and its teardown reset live
+			ws.resetCommands()
+			ws.resetSequences()
+			continue
+		}
+		ws.handleMessage(rec.Frame)
+		if r.OnFrame != nil {
+			r.OnFrame(at)
+		}
+	}
+
+	// A recorder killed mid-write leaves a truncated gzip stream; keep
+	// everything before the cut.
+	if err := sc.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, err
+	}
+	return false, nil
+}
+
+// RecordingFiles lists the recording files in dir in stream order.
+func RecordingFiles(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "kalshi-ws-*.jsonl*"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
@@ -0,0 +1,98 @@
+package kalshi
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"time"
+)
+
+// SettlementSource names the index or agency a series settles against.
+type SettlementSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Series is a template for recurring events, e.g. KXBTC15M.
+type Series struct {
+	Ticker            string             `json:"ticker"`
+	Title             string             `json:"title"`
+	Category          string             `json:"category"`
+	Frequency         string             `json:"frequency"` // e.g. "fifteen_min", "hourly", "daily"
+	SettlementSources []SettlementSource `json:"settlement_sources"`
+	ContractURL       string             `json:"contract_url"`
+	FeeType           string             `json:"fee_type"`
+	FeeMultiplier     float64            `json:"fee_multiplier"`
+	Tags              []string           `json:"tags"`
+}
+
+// Period returns how long each event of the series runs, from its
+// Frequency, or 0 for a frequency without a fixed period.
+func (s *Series) Period() time.Duration {
+	switch s.Frequency {
+	case "fifteen_min":
+		return 15 * time.Minute
+	case "hourly":
+		return time.Hour
+	case "daily":
+		return 24 * time.Hour
+	case "weekly":
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// Event groups the markets (strikes) of one series occurrence.
+type Event struct {
+	EventTicker       string   `json:"event_ticker"`
+	SeriesTicker      string   `json:"series_ticker"`
+	Title             string   `json:"title"`
+	SubTitle          string   `json:"sub_title"`
+	Category          string   `json:"category"`
+	MutuallyExclusive bool     `json:"mutually_exclusive"`
+	StrikeDate        string   `json:"strike_date"`
+	StrikePeriod      string   `json:"strike_period"`
+	Markets           []Market `json:"markets,omitempty"` // only with with_nested_markets=true
+}
+
+func (c *Client) GetSeries(ctx context.Context, seriesTicker string) (*Series, error) {
+	var result struct {
+		Series Series `json:"series"`
+	}
+	if err := c.get(ctx, "/series/"+seriesTicker, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Series, nil
+}
+
+// GetEvent returns an event and its markets.
+func (c *Client) GetEvent(ctx context.Context, eventTicker string) (*Event, []Market, error) {
+	var result struct {
+		Event   Event    `json:"event"`
+		Markets []Market `json:"markets"`
+	}
+	if err := c.get(ctx, "/events/"+eventTicker, nil, &result); err != nil {
+		return nil, nil, err
+	}
+	return &result.Event, result.Markets, nil
+}
+
+// GetEvents returns every event matching params (series_ticker, status,
+// with_nested_markets).
+func (c *Client) GetEvents(ctx context.Context, params url.Values) ([]Event, error) {
+	return collect(c.EventsIter(ctx, params))
+}
+
+// EventsIter yields pages of /events.
+func (c *Client) EventsIter(ctx context.Context, params url.Values) iter.Seq2[[]Event, error] {
+	return pages(ctx, func(ctx context.Context, cursor string) ([]Event, string, error) {
+		var result struct {
+			Events []Event `json:"events"`
+			Cursor string  `json:"cursor"`
+		}
+		if err := c.get(ctx, "/events", pageParams(params, cursor), &result); err != nil {
+			return nil, "", err
+		}
+		return result.Events, result.Cursor, nil
+	})
+}
@@ -0,0 +1,58 @@
+package kalshi
+
+import (
+	"context"
+	"iter"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// Settlement is the exchange's record of a settled market position.
+// Costs and revenue are in cents.
+type Settlement struct {
+	Ticker       string `json:"ticker"`
+	MarketResult string `json:"market_result"` // "yes" or "no"
+	YesCount     int    `json:"yes_count"`
+	YesTotalCost int    `json:"yes_total_cost"`
+	NoCount      int    `json:"no_count"`
+	NoTotalCost  int    `json:"no_total_cost"`
+	Revenue      int    `json:"revenue"`
+	FeeCost      string `json:"fee_cost"` // total fees on the position, in dollars (e.g. "0.3400")
+	SettledTime  string `json:"settled_time"`
+}
+
+// FeeCents returns FeeCost converted to cents.
+func (s *Settlement) FeeCents() int {
+	dollars, err := strconv.ParseFloat(s.FeeCost, 64)
+	if err != nil {
+		return 0
+	}
+	return int(math.Round(dollars * 100))
+}
+
+// PnL returns the realized P&L in cents: settlement revenue less the cost
+// of contracts held and fees paid.
+func (s *Settlement) PnL() int {
+	return s.Revenue - s.YesTotalCost - s.NoTotalCost - s.FeeCents()
+}
+
+// GetSettlements returns every portfolio settlement matching params
+// (ticker, event_ticker, min_ts, max_ts).
+func (c *Client) GetSettlements(ctx context.Context, params url.Values) ([]Settlement, error) {
+	return collect(c.SettlementsIter(ctx, params))
+}
+
+// SettlementsIter yields pages of /portfolio/settlements.
+func (c *Client) SettlementsIter(ctx context.Context, params url.Values) iter.Seq2[[]Settlement, error] {
+	return pages(ctx, func(ctx context.Context, cursor string) ([]Settlement, string, error) {
+		var result struct {
+			Settlements []Settlement `json:"settlements"`
+			Cursor      string       `json:"cursor"`
+		}
+		if err := c.get(ctx, "/portfolio/settlements", pageParams(params, cursor), &result); err != nil {
+			return nil, "", err
+		}
+		return result.Settlements, result.Cursor, nil
+	})
+}
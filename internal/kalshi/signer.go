@@ -0,0 +1,207 @@
+package kalshi
+
+import (
+	"bufio"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+)
+
+// Signer produces Kalshi request signatures: base64 RSA-PSS/SHA-256 over
+// timestamp+method+path, paired with the API key ID they belong to.
+type Signer interface {
+	KeyID() string
+	Sign(message string) (string, error)
+}
+
+// KeySigner signs with an in-memory RSA key.
+type KeySigner struct {
+	keyID string
+	key   *rsa.PrivateKey
+}
+
+func NewKeySigner(keyID string, key *rsa.PrivateKey) *KeySigner {
+	return &KeySigner{keyID: keyID, key: key}
+}
+
+func (s *KeySigner) KeyID() string { return s.keyID }
+
+func (s *KeySigner) Sign(message string) (string, error) {
+	return signPSS(s.key, message)
+}
+
+// NewPEMFileSigner loads an unencrypted PEM key from disk.
+func NewPEMFileSigner(keyID, path string) (*KeySigner, error) {
+	key, err := LoadPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(keyID, key), nil
+}
+
+// NewEnvSigner loads a key from an environment variable holding either the
+// PEM text (literal "\n" escapes allowed) or the base64-encoded PEM file.
+func NewEnvSigner(keyID, envVar string) (*KeySigner, error) {
+	v := os.Getenv(envVar)
+	if v == "" {
+		return nil, fmt.Errorf("%s is empty", envVar)
+	}
+
+	var data []byte
+	if strings.Contains(v, "-----BEGIN") {
+		data = []byte(strings.ReplaceAll(v, `\n`, "\n"))
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%s: not PEM and not base64: %w", envVar, err)
+		}
+		data = decoded
+	}
+
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", envVar, err)
+	}
+	return NewKeySigner(keyID, key), nil
+}
+
+// NewEncryptedPEMSigner loads a passphrase-protected PEM key (legacy
+// "Proc-Type: 4,ENCRYPTED" format, as written by `openssl rsa -aes256`).
+func NewEncryptedPEMSigner(keyID, path, passphrase string) (*KeySigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", path)
+	}
+	//lint:ignore SA1019 legacy PEM encryption is the only passphrase format the stdlib reads
+	if !x509.IsEncryptedPEMBlock(block) {
+		return nil, fmt.Errorf("%s is not an encrypted PEM key", path)
+	}
+	//lint:ignore SA1019 see above
+	der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", path, err)
+	}
+
+	key, err := parseKeyDER(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewKeySigner(keyID, key), nil
+}
+
+// SocketSigner delegates signing to an external process (HSM bridge,
+// PKCS#11 daemon, secrets agent) over a Unix socket, so the private key
+// never enters this process. One JSON line per request:
+//
+//	-> {"key_id": "...", "message": "<base64>"}
+//	<- {"signature": "<base64 RSA-PSS signature>"} or {"error": "..."}
+type SocketSigner struct {
+	keyID   string
+	path    string
+	timeout time.Duration
+}
+
+func NewSocketSigner(keyID, socketPath string) *SocketSigner {
+	return &SocketSigner{keyID: keyID, path: socketPath, timeout: 2 * time.Second}
+}
+
+func (s *SocketSigner) KeyID() string { return s.keyID }
+
+func (s *SocketSigner) Sign(message string) (string, error) {
+	conn, err := net.DialTimeout("unix", s.path, s.timeout)
+	if err != nil {
+		return "", fmt.Errorf("signer socket: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	req := struct {
+		KeyID   string `json:"key_id"`
+		Message string `json:"message"`
+	}{s.keyID, base64.StdEncoding.EncodeToString([]byte(message))}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return "", fmt.Errorf("signer socket write: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return "", fmt.Errorf("signer socket read: %w", err)
+	}
+	var resp struct {
+		Signature string `json:"signature"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return "", fmt.Errorf("signer socket response: %w", err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("signer socket: %s", resp.Error)
+	}
+	if resp.Signature == "" {
+		return "", fmt.Errorf("signer socket returned no signature")
+	}
+	return resp.Signature, nil
+}
+
+// RotatingSigner wraps a Signer that can be swapped at runtime, e.g. to
+// move to a new API key ID without restarting the bot.
+type RotatingSigner struct {
+	mu      sync.RWMutex
+	current Signer
+}
+
+func NewRotatingSigner(initial Signer) *RotatingSigner {
+	return &RotatingSigner{current: initial}
+}
+
+// Current returns the active signer. Use it to get a KeyID and signature
+// from the same key.
+func (r *RotatingSigner) Current() Signer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// Rotate makes next the active signer for all subsequent requests.
+func (r *RotatingSigner) Rotate(next Signer) {
+	r.mu.Lock()
+	r.current = next
+	r.mu.Unlock()
+}
+
+func (r *RotatingSigner) KeyID() string { return r.Current().KeyID() }
+
+func (r *RotatingSigner) Sign(message string) (string, error) {
+	return r.Current().Sign(message)
+}
+
+// NewSigner builds the signer selected by cfg.KalshiKeySource.
+func NewSigner(cfg *config.Config) (Signer, error) {
+	switch cfg.KalshiKeySource {
+	case "", "file":
+		return NewPEMFileSigner(cfg.KalshiAPIKeyID, cfg.KalshiPrivKeyPath)
+	case "env":
+		return NewEnvSigner(cfg.KalshiAPIKeyID, cfg.KalshiPrivKeyEnv)
+	case "encrypted":
+		return NewEncryptedPEMSigner(cfg.KalshiAPIKeyID, cfg.KalshiPrivKeyPath, cfg.KalshiPrivKeyPassphrase)
+	case "socket":
+		return NewSocketSigner(cfg.KalshiAPIKeyID, cfg.KalshiSignerSocket), nil
+	default:
+		return nil, fmt.Errorf("unknown key source %q", cfg.KalshiKeySource)
+	}
+}
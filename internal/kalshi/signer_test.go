@@ -0,0 +1,122 @@
+package kalshi
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	return key
+}
+
+func verifySig(t *testing.T, key *rsa.PrivateKey, message, sig string) {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		t.Fatalf("signature not base64: %v", err)
+	}
+	hash := sha256.Sum256([]byte(message))
+	if err := rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hash[:], raw, nil); err != nil {
+		t.Fatalf("signature does not verify: %v", err)
+	}
+}
+
+func TestEnvSignerAcceptsPEMAndBase64(t *testing.T) {
+	key := testKey(t)
+	pemText := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	cases := map[string]string{
+		"pem":     pemText,
+		"escaped": strings.ReplaceAll(pemText, "\n", `\n`),
+		"base64":  base64.StdEncoding.EncodeToString([]byte(pemText)),
+	}
+	for name, v := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("TEST_KALSHI_KEY", v)
+			s, err := NewEnvSigner("kid", "TEST_KALSHI_KEY")
+			if err != nil {
+				t.Fatalf("NewEnvSigner: %v", err)
+			}
+			sig, err := s.Sign("msg")
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			verifySig(t, key, "msg", sig)
+		})
+	}
+}
+
+func TestRotatingSignerAuthHeaders(t *testing.T) {
+	oldKey, newKey := testKey(t), testKey(t)
+	r := NewRotatingSigner(NewKeySigner("old", oldKey))
+
+	h, err := AuthHeaders(r, "GET", "/trade-api/v2/portfolio/balance")
+	if err != nil {
+		t.Fatalf("AuthHeaders: %v", err)
+	}
+	if h["KALSHI-ACCESS-KEY"] != "old" {
+		t.Errorf("key = %q, want old", h["KALSHI-ACCESS-KEY"])
+	}
+
+	r.Rotate(NewKeySigner("new", newKey))
+	h, err = AuthHeaders(r, "GET", "/trade-api/v2/portfolio/balance")
+	if err != nil {
+		t.Fatalf("AuthHeaders: %v", err)
+	}
+	if h["KALSHI-ACCESS-KEY"] != "new" {
+		t.Errorf("key = %q, want new", h["KALSHI-ACCESS-KEY"])
+	}
+	verifySig(t, newKey, h["KALSHI-ACCESS-TIMESTAMP"]+"GET/trade-api/v2/portfolio/balance", h["KALSHI-ACCESS-SIGNATURE"])
+}
+
+func TestSocketSigner(t *testing.T) {
+	key := testKey(t)
+	sock := filepath.Join(t.TempDir(), "signer.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var req struct {
+				KeyID   string `json:"key_id"`
+				Message string `json:"message"`
+			}
+			line, _ := bufio.NewReader(conn).ReadBytes('\n')
+			json.Unmarshal(line, &req)
+			msg, _ := base64.StdEncoding.DecodeString(req.Message)
+			sig, _ := signPSS(key, string(msg))
+			json.NewEncoder(conn).Encode(map[string]string{"signature": sig})
+			conn.Close()
+		}
+	}()
+
+	s := NewSocketSigner("kid", sock)
+	sig, err := s.Sign("hello")
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	verifySig(t, key, "hello", sig)
+}
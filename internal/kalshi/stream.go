@@ -0,0 +1,230 @@
+package kalshi
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventKind identifies what a StreamEvent carries.
+type EventKind int
+
+const (
+	EventBook      EventKind = iota + 1 // orderbook snapshot, delta or invalidation
+	EventTicker                         // ticker channel update
+	EventTrade                          // public trade
+	EventFill                           // one of our fills
+	EventOrder                          // one of our orders changed state
+	EventLifecycle                      // market lifecycle change
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventBook:
+		return "book"
+	case EventTicker:
+		return "ticker"
+	case EventTrade:
+		return "trade"
+	case EventFill:
+		return "fill"
+	case EventOrder:
+		return "order"
+	case EventLifecycle:
+		return "lifecycle"
+	}
+	return "unknown"
+}
+
+// StreamEvent is one update from the WS feed. Exactly one payload field is set,
+// matching Kind. Payloads are copies; receivers may keep them.
+type StreamEvent struct {
+	Kind   EventKind
+	Ticker string
+	Time   time.Time // when the message was received
+
+	Book      *OrderbookState
+	Update    *TickerUpdate
+	Trade     *TradeUpdate
+	Fill      *FillUpdate
+	Order     *Order
+	Lifecycle *LifecycleEvent
+}
+
+// SlowConsumerPolicy decides what happens when a subscriber's buffer is full.
+// The WS read loop never blocks on a subscriber.
+type SlowConsumerPolicy int
+
+const (
+	// DropNewest discards the event that didn't fit.
+	DropNewest SlowConsumerPolicy = iota
+	// DropOldest discards the oldest buffered event to make room.
+	DropOldest
+	// Disconnect closes the subscriber's channel.
+	Disconnect
+)
+
+// defaultEventBuffer is used when EventFilter.Buffer is zero.
+const defaultEventBuffer = 256
+
+// EventFilter selects which events a subscriber receives. Empty Kinds or
+// Tickers match everything; events without a ticker match any Tickers.
+type EventFilter struct {
+	Kinds   []EventKind
+	Tickers []string
+	Buffer  int
+	Policy  SlowConsumerPolicy
+}
+
+func (f *EventFilter) match(ev *StreamEvent) bool {
+	if len(f.Kinds) > 0 && !slices.Contains(f.Kinds, ev.Kind) {
+		return false
+	}
+	if len(f.Tickers) > 0 && ev.Ticker != "" && !slices.Contains(f.Tickers, ev.Ticker) {
+		return false
+	}
+	return true
+}
+
+// EventSubscription is a consumer's handle on the event bus. Receive from
+// C; call Close when done.
+type EventSubscription struct {
+	C <-chan StreamEvent
+
+	ch      chan StreamEvent
+	filter  EventFilter
+	bus     *eventBus
+	mu      sync.Mutex
+	closed  bool
+	dropped atomic.Int64
+}
+
+// Dropped returns how many events were discarded because the subscriber
+// fell behind.
+func (s *EventSubscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and closes C. It is safe to call more than once.
+func (s *EventSubscription) Close() {
+	s.bus.remove(s)
+	s.shutdown()
+}
+
+func (s *EventSubscription) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// deliver hands ev to the subscriber without blocking, applying the slow
+// consumer policy if the buffer is full.
+func (s *EventSubscription) deliver(ev StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.ch <- ev:
+		return
+	default:
+	}
+
+	s.dropped.Add(1)
+	switch s.filter.Policy {
+	case DropOldest:
+		select {
+		case <-s.ch:
+		default:
+		}
+		select {
+		case s.ch <- ev:
+		default:
+		}
+	case Disconnect:
+		s.closed = true
+		close(s.ch)
+		go s.bus.remove(s)
+	}
+}
+
+// eventBus fans WS events out to subscribers.
+type eventBus struct {
+	mu   sync.RWMutex
+	subs []*EventSubscription
+}
+
+func (b *eventBus) add(f EventFilter) *EventSubscription {
+	if f.Buffer <= 0 {
+		f.Buffer = defaultEventBuffer
+	}
+	ch := make(chan StreamEvent, f.Buffer)
+	s := &EventSubscription{C: ch, ch: ch, filter: f, bus: b}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, s)
+	b.mu.Unlock()
+	return s
+}
+
+func (b *eventBus) remove(s *EventSubscription) {
+	b.mu.Lock()
+	b.subs = slices.DeleteFunc(b.subs, func(x *EventSubscription) bool { return x == s })
+	b.mu.Unlock()
+}
+
+// wants reports whether any subscriber would receive an event of kind for
+// ticker, so publishers can skip building it.
+func (b *eventBus) wants(kind EventKind, ticker string) bool {
+	probe := StreamEvent{Kind: kind, Ticker: ticker}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subs {
+		if s.filter.match(&probe) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *eventBus) publish(ev StreamEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subs {
+		if s.filter.match(&ev) {
+			s.deliver(ev)
+		}
+	}
+}
+
+// SubscribeEvents registers a consumer for WS events matching filter.
+// Delivery never blocks the WS read loop: a subscriber whose buffer is full
+// loses events according to filter.Policy.
+func (ws *WSClient) SubscribeEvents(filter EventFilter) *EventSubscription {
+	return ws.bus.add(filter)
+}
+
+// publishBook sends a copy of ticker's book to interested subscribers.
+func (ws *WSClient) publishBook(ticker string) {
+	if !ws.bus.wants(EventBook, ticker) {
+		return
+	}
+
+	ws.obMu.RLock()
+	var snap *OrderbookState
+	if ob := ws.orderbooks[ticker]; ob != nil {
+		cp := *ob
+		snap = &cp
+	}
+	ws.obMu.RUnlock()
+
+	if snap != nil {
+		ws.bus.publish(StreamEvent{Kind: EventBook, Ticker: ticker, Time: ws.now(), Book: snap})
+	}
+}
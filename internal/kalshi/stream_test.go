@@ -0,0 +1,61 @@
+package kalshi
+
+import "testing"
+
+func TestSubscribeEventsFilters(t *testing.T) {
+	ws := newTestWSClient(t, "T1", "T2")
+	books := ws.SubscribeEvents(EventFilter{Kinds: []EventKind{EventBook}, Tickers: []string{"T1"}})
+	defer books.Close()
+
+	ws.handleMessage([]byte(testSnapshot))
+	ws.handleMessage([]byte(`{"type":"orderbook_snapshot","sid":1,"seq":2,"msg":{"market_ticker":"T2","yes":[[30,1]],"no":[]}}`))
+	ws.handleMessage([]byte(`{"type":"orderbook_delta","sid":1,"seq":3,"msg":{"market_ticker":"T1","price":42,"delta":3,"side":"yes"}}`))
+
+	if len(books.C) != 2 {
+		t.Fatalf("got %d events, want 2 (T1 snapshot + delta)", len(books.C))
+	}
+	<-books.C
+	ev := <-books.C
+	if ev.Kind != EventBook || ev.Ticker != "T1" || ev.Book.BestYesBid() != 42 {
+		t.Errorf("event = %+v", ev)
+	}
+
+	// Events carry copies: later deltas don't change a received book
+	ws.handleMessage([]byte(`{"type":"orderbook_delta","sid":1,"seq":4,"msg":{"market_ticker":"T1","price":45,"delta":1,"side":"yes"}}`))
+	if ev.Book.BestYesBid() != 42 {
+		t.Errorf("received book mutated to %d", ev.Book.BestYesBid())
+	}
+}
+
+func TestSlowConsumerPolicies(t *testing.T) {
+	var bus eventBus
+	newest := bus.add(EventFilter{Buffer: 2, Policy: DropNewest})
+	oldest := bus.add(EventFilter{Buffer: 2, Policy: DropOldest})
+	disc := bus.add(EventFilter{Buffer: 2, Policy: Disconnect})
+
+	for _, tk := range []string{"a", "b", "c"} {
+		bus.publish(StreamEvent{Kind: EventTrade, Ticker: tk})
+	}
+
+	if got := (<-newest.C).Ticker + (<-newest.C).Ticker; got != "ab" {
+		t.Errorf("DropNewest kept %q, want ab", got)
+	}
+	if got := (<-oldest.C).Ticker + (<-oldest.C).Ticker; got != "bc" {
+		t.Errorf("DropOldest kept %q, want bc", got)
+	}
+	if newest.Dropped() != 1 || oldest.Dropped() != 1 {
+		t.Errorf("dropped = %d/%d, want 1/1", newest.Dropped(), oldest.Dropped())
+	}
+
+	<-disc.C
+	<-disc.C
+	if _, ok := <-disc.C; ok {
+		t.Error("Disconnect should close the channel")
+	}
+
+	newest.Close()
+	newest.Close()
+	if _, ok := <-newest.C; ok {
+		t.Error("Close should close the channel")
+	}
+}
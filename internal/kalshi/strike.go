@@ -0,0 +1,210 @@
+package kalshi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// StrikeType is how a market's value is compared to its strike.
+type StrikeType string
+
+const (
+	StrikeGreater        StrikeType = "greater"          // YES if value > Floor
+	StrikeGreaterOrEqual StrikeType = "greater_or_equal" // YES if value >= Floor
+	StrikeLess           StrikeType = "less"             // YES if value < Cap
+	StrikeLessOrEqual    StrikeType = "less_or_equal"    // YES if value <= Cap
+	StrikeBetween        StrikeType = "between"          // YES if Floor <= value <= Cap
+	StrikeCustom         StrikeType = "custom"           // YES per the rules against the custom_strike target in Floor
+)
+
+// StrikeSpec is the structured settlement condition of a market.
+type StrikeSpec struct {
+	Type  StrikeType `json:"type"`
+	Floor float64    `json:"floor,omitempty"`
+	Cap   float64    `json:"cap,omitempty"`
+	Index string     `json:"index,omitempty"` // settlement index, e.g. "BRTI"
+	From  string     `json:"from"`            // "fields", "custom" or "rules" — where the bounds came from
+}
+
+// Strike returns the single threshold the market settles against: Floor
+// for greater-than markets, Cap for less-than markets, Floor for ranges.
+func (s StrikeSpec) Strike() float64 {
+	switch s.Type {
+	case StrikeLess, StrikeLessOrEqual:
+		return s.Cap
+	default:
+		return s.Floor
+	}
+}
+
+// YesIfAbove reports whether YES wins when the index settles above the strike.
+func (s StrikeSpec) YesIfAbove() bool {
+	return s.Type == StrikeGreater || s.Type == StrikeGreaterOrEqual
+}
+
+// ErrNoStrike is returned when neither the strike fields nor the rules text
+// yield a strike.
+var ErrNoStrike = errors.New("no strike found")
+
+const numberPattern = `\$?([\d,]+(?:\.\d+)?)`
+
+var (
+	rulesBetween = regexp.MustCompile(`(?i)\bbetween ` + numberPattern + ` and ` + numberPattern)
+	rulesPhrases = []struct {
+		re  *regexp.Regexp
+		typ StrikeType
+	}{
+		{regexp.MustCompile(`(?i)\b(?:is at least|(?:is )?greater than or equal to|(?:is )?at or above) ` + numberPattern), StrikeGreaterOrEqual},
+		{regexp.MustCompile(`(?i)\b` + numberPattern + ` or (?:above|more|higher)\b`), StrikeGreaterOrEqual},
+		{regexp.MustCompile(`(?i)\b(?:is )?(?:above|greater than|more than|higher than) ` + numberPattern), StrikeGreater},
+		{regexp.MustCompile(`(?i)\b(?:is at most|(?:is )?less than or equal to|(?:is )?at or below) ` + numberPattern), StrikeLessOrEqual},
+		{regexp.MustCompile(`(?i)\b` + numberPattern + ` or (?:below|less|lower)\b`), StrikeLessOrEqual},
+		{regexp.MustCompile(`(?i)\b(?:is )?(?:below|less than|lower than) ` + numberPattern), StrikeLess},
+	}
+	rulesIndex = regexp.MustCompile(`\(([A-Z][A-Z0-9_]{2,})\)`)
+)
+
+// ParseStrike extracts the settlement condition of a market. The exchange's
+// strike_type/floor_strike/cap_strike fields win when present, then a
+// numeric custom_strike target; otherwise the condition is parsed from
+// rules_primary.
+func ParseStrike(m *Market) (StrikeSpec, error) {
+	spec := StrikeSpec{Index: parseIndex(m.RulesPrimary)}
+
+	if m.FloorStrike > 0 || m.CapStrike > 0 {
+		spec.Type = StrikeType(m.StrikeType)
+		if spec.Type == "" {
+			spec.Type = inferStrikeType(m)
+		}
+		spec.Floor = m.FloorStrike
+		spec.Cap = m.CapStrike
+		spec.From = "fields"
+		if spec.Strike() > 0 {
+			return spec, nil
+		}
+	}
+
+	if target, index, ok := parseCustomStrike(m.CustomStrike); ok {
+		spec.Type = StrikeCustom
+		spec.Floor = target
+		spec.From = "custom"
+		if spec.Index == "" {
+			spec.Index = index
+		}
+		return spec, nil
+	}
+
+	rules, err := ParseRulesStrike(m.RulesPrimary)
+	if err != nil {
+		return StrikeSpec{}, fmt.Errorf("%s: %w", m.Ticker, err)
+	}
+	rules.Index = spec.Index
+	return rules, nil
+}
+
+// ParseRulesStrike parses the settlement condition from rules text such as
+// "If the simple average ... is at least 70382.44, then the market resolves to Yes."
+func ParseRulesStrike(rules string) (StrikeSpec, error) {
+	if rules == "" {
+		return StrikeSpec{}, ErrNoStrike
+	}
+
+	if m := rulesBetween.FindStringSubmatch(rules); m != nil {
+		lo, err1 := parseNumber(m[1])
+		hi, err2 := parseNumber(m[2])
+		if err1 == nil && err2 == nil && lo > 0 && hi >= lo {
+			return StrikeSpec{Type: StrikeBetween, Floor: lo, Cap: hi, From: "rules"}, nil
+		}
+	}
+
+	for _, p := range rulesPhrases {
+		m := p.re.FindStringSubmatch(rules)
+		if m == nil {
+			continue
+		}
+		v, err := parseNumber(m[1])
+		if err != nil || v <= 0 {
+			continue
+		}
+		spec := StrikeSpec{Type: p.typ, From: "rules"}
+		if p.typ == StrikeLess || p.typ == StrikeLessOrEqual {
+			spec.Cap = v
+		} else {
+			spec.Floor = v
+		}
+		return spec, nil
+	}
+
+	return StrikeSpec{}, ErrNoStrike
+}
+
+// parseCustomStrike reads a single numeric target from custom_strike, which
+// is either a number, a numeric string, or an object mapping a target name
+// (returned as index) to one. Objects with several numeric targets are
+// ambiguous and rejected.
+func parseCustomStrike(raw json.RawMessage) (target float64, index string, ok bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, "", false
+	}
+	if v, ok := customNumber(raw); ok {
+		return v, "", true
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return 0, "", false
+	}
+	found := 0
+	for k, rv := range obj {
+		if v, ok := customNumber(rv); ok {
+			target, index = v, k
+			found++
+		}
+	}
+	return target, index, found == 1
+}
+
+// customNumber decodes a positive number or numeric string.
+func customNumber(raw json.RawMessage) (float64, bool) {
+	var v float64
+	if err := json.Unmarshal(raw, &v); err == nil {
+		return v, v > 0
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return 0, false
+	}
+	v, err := parseNumber(strings.TrimPrefix(strings.TrimSpace(str), "$"))
+	return v, err == nil && v > 0
+}
+
+// inferStrikeType guesses the comparison for markets that carry bounds but
+// no strike_type, preferring the rules text when it parses.
+func inferStrikeType(m *Market) StrikeType {
+	if rules, err := ParseRulesStrike(m.RulesPrimary); err == nil {
+		return rules.Type
+	}
+	switch {
+	case m.FloorStrike > 0 && m.CapStrike > 0:
+		return StrikeBetween
+	case m.CapStrike > 0:
+		return StrikeLess
+	default:
+		return StrikeGreater
+	}
+}
+
+func parseIndex(rules string) string {
+	if m := rulesIndex.FindStringSubmatch(rules); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+}
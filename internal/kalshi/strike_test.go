@@ -0,0 +1,79 @@
+package kalshi
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestParseStrikeGolden runs ParseStrike over a corpus of market rules and
+// compares against testdata/strike_rules.golden.json. Run with -update to
+// regenerate the golden file after an intended change, then review the diff.
+func TestParseStrikeGolden(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "strike_rules.json"))
+	if err != nil {
+		t.Fatalf("reading corpus: %v", err)
+	}
+	var corpus []struct {
+		Name   string `json:"name"`
+		Market Market `json:"market"`
+	}
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		t.Fatalf("parsing corpus: %v", err)
+	}
+
+	type result struct {
+		Name   string      `json:"name"`
+		Spec   *StrikeSpec `json:"spec,omitempty"`
+		Strike float64     `json:"strike"`
+		Error  string      `json:"error,omitempty"`
+	}
+	var got []result
+	for _, c := range corpus {
+		r := result{Name: c.Name}
+		spec, err := ParseStrike(&c.Market)
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Spec = &spec
+			r.Strike = spec.Strike()
+		}
+		got = append(got, r)
+	}
+
+	out, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = append(out, '\n')
+
+	golden := filepath.Join("testdata", "strike_rules.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, out, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create): %v", err)
+	}
+	if string(want) != string(out) {
+		t.Errorf("ParseStrike output differs from %s; run with -update and review the diff\ngot:\n%s", golden, out)
+	}
+}
+
+func TestStrikePriceMatchesParse(t *testing.T) {
+	m := Market{RulesPrimary: "... Index (BRTI) before 5:30 PM EST is at least 70382.44, then ..."}
+	if got := m.StrikePrice(); got != 70382.44 {
+		t.Errorf("StrikePrice() = %v, want 70382.44", got)
+	}
+	if got := (&Market{RulesPrimary: "no number here"}).StrikePrice(); got != 0 {
+		t.Errorf("unparseable StrikePrice() = %v, want 0", got)
+	}
+}
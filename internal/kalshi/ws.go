@@ -2,117 +2,121 @@ package kalshi
 
 import (
 	"context"
-	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/sdibella/kalshi-btc15m/internal/config"
 )
 
-// WSClient manages a WebSocket connection to Kalshi for real-time orderbook data.
+// WSClient manages a WebSocket connection to Kalshi for real-time orderbook,
+// ticker, trade, fill, order and market lifecycle data.
 type WSClient struct {
-	cfg     *config.Config
-	privKey *rsa.PrivateKey
-	conn    *websocket.Conn
-	mu      sync.RWMutex
+	cfg    *config.Config
+	signer Signer
+	conn   *websocket.Conn
+	mu     sync.RWMutex
+	// writeMu serializes writes; gorilla allows one concurrent writer
+	writeMu sync.Mutex
 
 	// orderbooks maps ticker -> OrderbookState
 	orderbooks map[string]*OrderbookState
 	obMu       sync.RWMutex
 
+	// Sequence tracking, guarded by obMu. Kalshi numbers messages per
+	// subscription (sid); each book is owned by the sid whose snapshot
+	// built it, and only that sid's deltas are applied to it.
+	sidSeq    map[int64]int64  // sid -> last seq seen
+	bookSid   map[string]int64 // ticker -> owning sid
+	abandoned map[int64]bool   // sids dropped after a gap; messages ignored
+	seqGaps   atomic.Int64
+	cmdErrors atomic.Int64 // commands Kalshi answered with an error
+
 	// subscription tracking for auto-resubscribe on reconnect
 	subscribedTickers map[string]bool
 	subMu             sync.RWMutex
-}
 
-// OrderbookState holds the current state of an orderbook for a ticker.
-type OrderbookState struct {
-	Ticker     string
-	Yes        []PriceLevel // sorted best->worst
-	No         []PriceLevel
-	LastUpdate time.Time // when this orderbook was last updated (snapshot or delta)
-}
+	// latest ticker, trade, fill, order and lifecycle pushes
+	channels *channelState
 
-type PriceLevel struct {
-	Price    int
-	Quantity int
-}
+	// fan-out of every update to SubscribeEvents consumers
+	bus *eventBus
 
-func (ob *OrderbookState) BestYesBid() int {
-	if len(ob.Yes) > 0 {
-		return ob.Yes[0].Price
-	}
-	return 0
-}
+	health wsHealthState
 
-func (ob *OrderbookState) BestYesAsk() int {
-	if len(ob.No) > 0 {
-		return 100 - ob.No[0].Price
-	}
-	return 100
-}
+	// now timestamps book updates and events; a Replayer substitutes the
+	// recorded receive time
+	now func() time.Time
 
-// AskDepth returns ask-side depth for buying a given side, sorted best
-// (lowest ask price) first. Buying YES walks the NO side; buying NO walks
-// the YES side. Prices are converted to the buyer's perspective.
-func (ob *OrderbookState) AskDepth(side string) []PriceLevel {
-	var source []PriceLevel
-	if side == "yes" {
-		source = ob.No
-	} else {
-		source = ob.Yes
-	}
+	// recorder, if set, captures every received frame
+	recorder *Recorder
 
-	levels := make([]PriceLevel, 0, len(source))
-	for _, l := range source {
-		levels = append(levels, PriceLevel{
-			Price:    100 - l.Price,
-			Quantity: l.Quantity,
-		})
-	}
-	// source is sorted highest-price-first, so after 100-x conversion
-	// the result is already sorted lowest-price-first (best ask first).
-	return levels
+	// Command IDs, commands awaiting a response, and live subscriptions by
+	// sid on the current connection.
+	cmdMu     sync.Mutex
+	nextCmdID int64
+	pending   map[int64]*wsPending
+	subs      map[int64]*wsSubscription
 }
 
-func NewWSClient(cfg *config.Config) (*WSClient, error) {
-	key, err := LoadPrivateKey(cfg.KalshiPrivKeyPath)
-	if err != nil {
-		return nil, err
-	}
-
+func NewWSClient(cfg *config.Config, signer Signer) (*WSClient, error) {
 	return &WSClient{
 		cfg:               cfg,
-		privKey:           key,
+		signer:            signer,
 		orderbooks:        make(map[string]*OrderbookState),
+		sidSeq:            make(map[int64]int64),
+		bookSid:           make(map[string]int64),
+		abandoned:         make(map[int64]bool),
 		subscribedTickers: make(map[string]bool),
+		channels:          newChannelState(),
+		bus:               &eventBus{},
+		pending:           make(map[int64]*wsPending),
+		subs:              make(map[int64]*wsSubscription),
+		now:               time.Now,
 	}, nil
 }
 
-// Run connects to the Kalshi WebSocket and processes messages.
+// Run connects to the Kalshi WebSocket and processes messages, reconnecting
+// with jittered exponential backoff until ctx is done.
 func (ws *WSClient) Run(ctx context.Context) error {
+	attempt := 0
 	for {
-		if err := ws.connect(ctx); err != nil {
+		if err := ws.connect(ctx); err != nil && ctx.Err() == nil {
 			slog.Warn("kalshi ws disconnected", "err", err)
 		}
-		select {
-		case <-ctx.Done():
+		if up := ws.health.disconnected(); up >= wsStableAfter {
+			attempt = 0
+		}
+		if ctx.Err() != nil {
 			return ctx.Err()
-		case <-time.After(2 * time.Second):
-			slog.Info("kalshi ws reconnecting...")
 		}
+
+		delay := reconnectDelay(attempt)
+		attempt++
+		slog.Info("kalshi ws reconnecting", "in", delay.Round(time.Millisecond), "attempt", attempt)
+		if err := sleepCtx(ctx, delay); err != nil {
+			return err
+		}
+		ws.health.reconnecting()
 	}
 }
 
 func (ws *WSClient) connect(ctx context.Context) error {
 	wsURL := ws.cfg.WSBaseURL()
+	parsed, err := url.Parse(wsURL)
+	if err != nil {
+		return fmt.Errorf("parsing ws URL: %w", err)
+	}
 
 	// Generate auth headers for the WS handshake
-	headers, err := AuthHeaders(ws.cfg, ws.privKey, "GET", "/trade-api/ws/v2")
+	headers, err := AuthHeaders(ws.signer, "GET", parsed.Path)
 	if err != nil {
 		return fmt.Errorf("generating ws auth: %w", err)
 	}
@@ -131,23 +135,36 @@ func (ws *WSClient) connect(ctx context.Context) error {
 	ws.mu.Lock()
 	ws.conn = conn
 	ws.mu.Unlock()
+	ws.health.connected()
+
+	done := make(chan struct{})
+	ws.keepalive(ctx, conn, done)
 
 	defer func() {
+		close(done)
 		conn.Close()
 		ws.mu.Lock()
 		ws.conn = nil
 		ws.mu.Unlock()
+		ws.resetCommands()
 	}()
 
 	slog.Info("kalshi ws connected")
 
+	// Sids are per connection, and deltas may have been missed while
+	// disconnected: distrust every book until its new snapshot arrives.
+	ws.resetSequences()
+	if ws.recorder != nil {
+		ws.recorder.RecordConnect(time.Now())
+	}
+
+	// Account and lifecycle streams aren't tied to a market
+	ws.sendAsync(conn, "subscribe", wsCommandParams{Channels: globalChannels}, len(globalChannels))
+
 	// Re-subscribe to any previously tracked tickers
 	if tickers := ws.subscribedTickerList(); len(tickers) > 0 {
-		if err := ws.sendSubscribe(conn, tickers); err != nil {
-			slog.Warn("kalshi ws resubscribe failed", "err", err, "tickers", len(tickers))
-		} else {
-			slog.Info("kalshi ws resubscribed", "tickers", len(tickers))
-		}
+		ws.sendAsync(conn, "subscribe", wsCommandParams{Channels: marketChannels, MarketTickers: tickers}, len(marketChannels))
+		slog.Info("kalshi ws resubscribing", "tickers", len(tickers))
 	}
 
 	for {
@@ -157,19 +174,32 @@ func (ws *WSClient) connect(ctx context.Context) error {
 		default:
 		}
 
-		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
 		_, msg, err := conn.ReadMessage()
 		if err != nil {
 			return err
 		}
 
+		ws.health.received()
+		if ws.recorder != nil {
+			ws.recorder.Record(time.Now(), msg)
+		}
 		ws.handleMessage(msg)
 	}
 }
 
-// Subscribe sends a subscription command for orderbook_delta on the given tickers.
-// Tickers are tracked so they are automatically re-subscribed on reconnect.
+// Subscribe sends a subscription command for the orderbook_delta, ticker and
+// trade channels on the given tickers and waits for Kalshi to acknowledge
+// it. Tickers are tracked so they are automatically re-subscribed on
+// reconnect.
 func (ws *WSClient) Subscribe(tickers []string) error {
+	return <-ws.SubscribeAsync(tickers)
+}
+
+// SubscribeAsync is Subscribe without the wait: the result arrives on the
+// returned channel, at the latest after wsCommandTimeout. While
+// disconnected it is nil at once, as the tickers are subscribed on connect.
+func (ws *WSClient) SubscribeAsync(tickers []string) <-chan error {
 	ws.subMu.Lock()
 	for _, t := range tickers {
 		ws.subscribedTickers[t] = true
@@ -182,14 +212,28 @@ func (ws *WSClient) Subscribe(tickers []string) error {
 
 	if conn == nil {
 		// Not connected yet -- tickers are tracked and will be subscribed on connect
-		return nil
+		return commandFailed(nil)
 	}
 
-	return ws.sendSubscribe(conn, tickers)
+	p, err := ws.send(conn, "subscribe", wsCommandParams{Channels: marketChannels, MarketTickers: tickers}, len(marketChannels), false)
+	if err != nil {
+		return commandFailed(err)
+	}
+	return p.done
+}
+
+// Unsubscribe stops streaming tickers (used when markets settle). Local
+// state is dropped at once; subscriptions left with no markets are
+// unsubscribed on the server and the rest are trimmed with
+// update_subscription. Command failures are returned. Tickers whose
+// subscribe is still in flight are removed when it is acknowledged.
+func (ws *WSClient) Unsubscribe(tickers []string) error {
+	return <-ws.UnsubscribeAsync(tickers)
 }
 
-// Unsubscribe removes tickers from tracking (used when markets settle).
-func (ws *WSClient) Unsubscribe(tickers []string) {
+// UnsubscribeAsync is Unsubscribe without the wait: local state is dropped
+// at once and the commands' results arrive on the returned channel.
+func (ws *WSClient) UnsubscribeAsync(tickers []string) <-chan error {
 	ws.subMu.Lock()
 	for _, t := range tickers {
 		delete(ws.subscribedTickers, t)
@@ -199,20 +243,22 @@ func (ws *WSClient) Unsubscribe(tickers []string) {
 	ws.obMu.Lock()
 	for _, t := range tickers {
 		delete(ws.orderbooks, t)
+		delete(ws.bookSid, t)
 	}
 	ws.obMu.Unlock()
-}
 
-func (ws *WSClient) sendSubscribe(conn *websocket.Conn, tickers []string) error {
-	cmd := wsCommand{
-		ID:  1,
-		Cmd: "subscribe",
-		Params: wsSubscribeParams{
-			Channels:      []string{"orderbook_delta"},
-			MarketTickers: tickers,
-		},
+	ws.channels.forgetMarkets(tickers)
+
+	drop, trim := ws.removeTickers(tickers, "")
+
+	ws.mu.RLock()
+	conn := ws.conn
+	ws.mu.RUnlock()
+	if conn == nil {
+		return commandFailed(nil) // sids die with the connection
 	}
-	return conn.WriteJSON(cmd)
+
+	return ws.sendRemovals(conn, drop, trim, false)
 }
 
 func (ws *WSClient) subscribedTickerList() []string {
@@ -225,26 +271,31 @@ func (ws *WSClient) subscribedTickerList() []string {
 	return tickers
 }
 
-// GetOrderbook returns the current orderbook state for a ticker.
+// GetOrderbook returns a snapshot of the current orderbook for a ticker, or
+// nil if there is none or it is stale after a sequence gap. The snapshot is
+// the caller's own copy; later updates don't touch it.
 func (ws *WSClient) GetOrderbook(ticker string) *OrderbookState {
 	ws.obMu.RLock()
 	defer ws.obMu.RUnlock()
-	return ws.orderbooks[ticker]
-}
-
-type wsCommand struct {
-	ID     int              `json:"id"`
-	Cmd    string           `json:"cmd"`
-	Params wsSubscribeParams `json:"params"`
+	ob := ws.orderbooks[ticker]
+	if ob == nil || ob.Stale {
+		return nil
+	}
+	snap := *ob
+	return &snap
 }
 
-type wsSubscribeParams struct {
-	Channels      []string `json:"channels"`
-	MarketTickers []string `json:"market_tickers"`
+// SeqGaps returns how many times a book was invalidated because of a
+// sequence gap or an inconsistent delta since the client was created.
+func (ws *WSClient) SeqGaps() int64 {
+	return ws.seqGaps.Load()
 }
 
 type wsMessage struct {
+	ID   int64           `json:"id"` // set on command responses
 	Type string          `json:"type"`
+	Sid  int64           `json:"sid"`
+	Seq  int64           `json:"seq"`
 	Msg  json.RawMessage `json:"msg"`
 }
 
@@ -274,7 +325,11 @@ func (ws *WSClient) handleMessage(data []byte) {
 			slog.Warn("bad orderbook snapshot", "err", err)
 			return
 		}
-		ws.applySnapshot(snap)
+		if err := ws.applySnapshot(msg.Sid, msg.Seq, snap); err != nil {
+			ws.resync(msg.Sid, snap.Ticker, err)
+			return
+		}
+		ws.publishBook(snap.Ticker)
 
 	case "orderbook_delta":
 		var delta wsOrderbookDelta
@@ -282,74 +337,186 @@ func (ws *WSClient) handleMessage(data []byte) {
 			slog.Warn("bad orderbook delta", "err", err)
 			return
 		}
-		ws.applyDelta(delta)
+		if err := ws.applyDelta(msg.Sid, msg.Seq, delta); err != nil {
+			ws.resync(msg.Sid, delta.Ticker, err)
+			return
+		}
+		ws.publishBook(delta.Ticker)
 
 	default:
-		slog.Info("kalshi ws unhandled message", "type", msg.Type, "msg", string(msg.Msg))
+		if !ws.handleCommandResponse(msg) && !ws.handleChannelMessage(msg) {
+			slog.Info("kalshi ws unhandled message", "type", msg.Type, "msg", string(msg.Msg))
+		}
 	}
 }
 
-func (ws *WSClient) applySnapshot(snap wsOrderbookSnapshot) {
-	ob := &OrderbookState{Ticker: snap.Ticker}
+// errSeqGap means messages on a subscription were skipped; every book the
+// subscription owns is suspect.
+type errSeqGap struct {
+	sid, want, got int64
+}
 
-	for _, level := range snap.Yes {
-		if len(level) >= 2 {
-			ob.Yes = append(ob.Yes, PriceLevel{Price: level[0], Quantity: level[1]})
-		}
+func (e *errSeqGap) Error() string {
+	return fmt.Sprintf("sid %d: expected seq %d, got %d", e.sid, e.want, e.got)
+}
+
+// advanceSeq records seq for sid. It reports false for messages on an
+// abandoned subscription, which must be dropped. Caller holds obMu.
+func (ws *WSClient) advanceSeq(sid, seq int64) (bool, error) {
+	if ws.abandoned[sid] {
+		return false, nil
 	}
-	for _, level := range snap.No {
-		if len(level) >= 2 {
-			ob.No = append(ob.No, PriceLevel{Price: level[0], Quantity: level[1]})
-		}
+	last, seen := ws.sidSeq[sid]
+	ws.sidSeq[sid] = seq
+	if seen && seq != last+1 {
+		return false, &errSeqGap{sid: sid, want: last + 1, got: seq}
 	}
+	return true, nil
+}
 
-	ob.LastUpdate = time.Now()
+func (ws *WSClient) applySnapshot(sid, seq int64, snap wsOrderbookSnapshot) error {
+	ob := &OrderbookState{Ticker: snap.Ticker}
+	add := func(side string, levels [][]int) error {
+		for _, level := range levels {
+			if len(level) >= 2 {
+				if err := ob.add(side, level[0], level[1]); err != nil {
+					return fmt.Errorf("snapshot: %w", err)
+				}
+			}
+		}
+		return nil
+	}
+	if err := add("yes", snap.Yes); err != nil {
+		return err
+	}
+	if err := add("no", snap.No); err != nil {
+		return err
+	}
+	ob.LastUpdate = ws.now()
 
 	ws.obMu.Lock()
-	ws.orderbooks[snap.Ticker] = ob
+	ok, err := ws.advanceSeq(sid, seq)
+	if ok {
+		ws.orderbooks[snap.Ticker] = ob
+		ws.bookSid[snap.Ticker] = sid
+	}
 	ws.obMu.Unlock()
+	if !ok {
+		return err
+	}
 
-	slog.Debug("orderbook snapshot", "ticker", snap.Ticker, "yesLevels", len(ob.Yes), "noLevels", len(ob.No))
+	yes, no := ob.Levels()
+	slog.Debug("orderbook snapshot", "ticker", snap.Ticker, "sid", sid, "yesLevels", yes, "noLevels", no)
+	return nil
 }
 
-func (ws *WSClient) applyDelta(delta wsOrderbookDelta) {
+// applyDelta applies one level change. It returns an error if the delta
+// can't be applied consistently: a sequence gap, a bad price, or a removal
+// of more than the book holds.
+func (ws *WSClient) applyDelta(sid, seq int64, delta wsOrderbookDelta) error {
 	ws.obMu.Lock()
 	defer ws.obMu.Unlock()
 
+	if ok, err := ws.advanceSeq(sid, seq); !ok {
+		return err
+	}
+
 	ob := ws.orderbooks[delta.Ticker]
-	if ob == nil {
-		return
+	if ob == nil || ob.Stale || ws.bookSid[delta.Ticker] != sid {
+		return nil // no book yet, or awaiting a snapshot from a newer sid
 	}
-	ob.LastUpdate = time.Now()
+	ob.LastUpdate = ws.now()
+	return ob.add(delta.Side, delta.Price, delta.Delta)
+}
 
-	var levels *[]PriceLevel
-	if delta.Side == "yes" {
-		levels = &ob.Yes
+// resync invalidates books after an inconsistency and re-subscribes them to
+// get fresh snapshots. A sequence gap abandons the whole subscription and
+// every book it owns; a bad delta only affects its own ticker.
+func (ws *WSClient) resync(sid int64, ticker string, cause error) {
+	ws.seqGaps.Add(1)
+
+	// A gap drops the whole subscription; a bad delta only moves its
+	// ticker to a new one.
+	var gap *errSeqGap
+	isGap := errors.As(cause, &gap)
+	var drop []int64
+	var trim map[int64][]string
+	affected := []string{ticker}
+	if isGap {
+		drop = []int64{sid}
+		for _, t := range ws.dropSubscription(sid) {
+			if t != ticker {
+				affected = append(affected, t)
+			}
+		}
 	} else {
-		levels = &ob.No
+		drop, trim = ws.removeTickers([]string{ticker}, "orderbook_delta")
 	}
 
-	// Find existing level at this price
-	for i, l := range *levels {
-		if l.Price == delta.Price {
-			newQty := l.Quantity + delta.Delta
-			if newQty <= 0 {
-				*levels = append((*levels)[:i], (*levels)[i+1:]...)
-			} else {
-				(*levels)[i].Quantity = newQty
+	ws.obMu.Lock()
+	for _, d := range drop {
+		ws.abandoned[d] = true
+		delete(ws.sidSeq, d)
+	}
+	if isGap {
+		for t, owner := range ws.bookSid {
+			if owner == sid && !slices.Contains(affected, t) {
+				affected = append(affected, t)
 			}
-			return
 		}
 	}
+	for _, t := range affected {
+		if ob := ws.orderbooks[t]; ob != nil {
+			ob.Stale = true
+		}
+		delete(ws.bookSid, t)
+	}
+	ws.obMu.Unlock()
+
+	for _, t := range affected {
+		ws.publishBook(t)
+	}
 
-	// New price level
-	if delta.Delta > 0 {
-		*levels = append(*levels, PriceLevel{Price: delta.Price, Quantity: delta.Delta})
-		// Keep sorted (highest price first for yes, highest for no)
-		for i := len(*levels) - 1; i > 0; i-- {
-			if (*levels)[i].Price > (*levels)[i-1].Price {
-				(*levels)[i], (*levels)[i-1] = (*levels)[i-1], (*levels)[i]
-			}
+	// Only re-subscribe tickers we still want
+	ws.subMu.RLock()
+	tickers := affected[:0]
+	for _, t := range affected {
+		if ws.subscribedTickers[t] {
+			tickers = append(tickers, t)
 		}
 	}
+	ws.subMu.RUnlock()
+
+	slog.Warn("kalshi ws orderbook out of sync, resubscribing",
+		"sid", sid,
+		"cause", cause,
+		"tickers", tickers,
+		"gaps", ws.seqGaps.Load(),
+	)
+
+	ws.mu.RLock()
+	conn := ws.conn
+	ws.mu.RUnlock()
+	if conn == nil {
+		return
+	}
+	// Stop the old stream before asking for a new one, so Kalshi doesn't
+	// see a duplicate subscription
+	ws.sendRemovals(conn, drop, trim, true)
+	if len(tickers) > 0 {
+		ws.sendAsync(conn, "subscribe", wsCommandParams{Channels: []string{"orderbook_delta"}, MarketTickers: tickers}, 1)
+	}
+}
+
+// resetSequences forgets all sid state and marks every book stale. Called
+// on each new connection.
+func (ws *WSClient) resetSequences() {
+	ws.obMu.Lock()
+	defer ws.obMu.Unlock()
+	clear(ws.sidSeq)
+	clear(ws.bookSid)
+	clear(ws.abandoned)
+	for _, ob := range ws.orderbooks {
+		ob.Stale = true
+	}
 }
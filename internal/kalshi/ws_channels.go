@@ -0,0 +1,248 @@
+package kalshi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Channels subscribed per market ticker, and once per connection for the
+// account and lifecycle streams.
+var (
+	marketChannels = []string{"orderbook_delta", "ticker", "trade"}
+	globalChannels = []string{"fill", "user_orders", "market_lifecycle_v2"}
+)
+
+// TickerUpdate is a push from the ticker channel: top of book, last price
+// and volume for one market.
+type TickerUpdate struct {
+	Ticker       string `json:"market_ticker"`
+	Price        int    `json:"price"`
+	YesBid       int    `json:"yes_bid"`
+	YesAsk       int    `json:"yes_ask"`
+	Volume       int    `json:"volume"`
+	OpenInterest int    `json:"open_interest"`
+	TS           int64  `json:"ts"`
+}
+
+// TradeUpdate is one execution from the public trade channel.
+type TradeUpdate struct {
+	TradeID   string `json:"trade_id"`
+	Ticker    string `json:"market_ticker"`
+	YesPrice  int    `json:"yes_price"`
+	NoPrice   int    `json:"no_price"`
+	Count     int    `json:"count"`
+	TakerSide string `json:"taker_side"`
+	TS        int64  `json:"ts"`
+}
+
+// FillUpdate is one of our own fills from the authenticated fill channel.
+type FillUpdate struct {
+	TradeID       string `json:"trade_id"`
+	OrderID       string `json:"order_id"`
+	ClientOrderID string `json:"client_order_id"`
+	Ticker        string `json:"market_ticker"`
+	IsTaker       bool   `json:"is_taker"`
+	Side          string `json:"side"`
+	Action        string `json:"action"`
+	YesPrice      int    `json:"yes_price"`
+	NoPrice       int    `json:"no_price"`
+	Count         int    `json:"count"`
+	TS            int64  `json:"ts"`
+}
+
+// LifecycleEvent is a market state change: "created", "activated",
+// "deactivated", "close_date_updated", "determined" or "settled".
+type LifecycleEvent struct {
+	Ticker          string `json:"market_ticker"`
+	EventType       string `json:"event_type"`
+	OpenTS          int64  `json:"open_ts"`
+	CloseTS         int64  `json:"close_ts"`
+	Result          string `json:"result"`
+	DeterminationTS int64  `json:"determination_ts"`
+	SettledTS       int64  `json:"settled_ts"`
+}
+
+// channelState holds the latest data from the non-orderbook channels.
+type channelState struct {
+	mu         sync.RWMutex
+	tickers    map[string]TickerUpdate
+	lastTrades map[string]TradeUpdate
+	orders     map[string]Order     // order ID -> latest user_orders push
+	lastFills  map[string]time.Time // order ID -> when its last fill arrived
+	results    map[string]string    // ticker -> result from a "determined" event
+}
+
+func newChannelState() *channelState {
+	return &channelState{
+		tickers:    make(map[string]TickerUpdate),
+		lastTrades: make(map[string]TradeUpdate),
+		orders:     make(map[string]Order),
+		lastFills:  make(map[string]time.Time),
+		results:    make(map[string]string),
+	}
+}
+
+// handleChannelMessage decodes a non-orderbook message, records it and
+// publishes it to event subscribers. It reports false for message types it
+// doesn't know.
+func (ws *WSClient) handleChannelMessage(msg wsMessage) bool {
+	cs := ws.channels
+	ev := StreamEvent{Time: ws.now()}
+
+	switch msg.Type {
+	case "ticker":
+		var u TickerUpdate
+		if err := json.Unmarshal(msg.Msg, &u); err != nil {
+			slog.Warn("bad ticker update", "err", err)
+			return true
+		}
+		cs.mu.Lock()
+		cs.tickers[u.Ticker] = u
+		cs.mu.Unlock()
+		ev.Kind, ev.Ticker, ev.Update = EventTicker, u.Ticker, &u
+
+	case "trade":
+		var u TradeUpdate
+		if err := json.Unmarshal(msg.Msg, &u); err != nil {
+			slog.Warn("bad trade update", "err", err)
+			return true
+		}
+		cs.mu.Lock()
+		cs.lastTrades[u.Ticker] = u
+		cs.mu.Unlock()
+		ev.Kind, ev.Ticker, ev.Trade = EventTrade, u.Ticker, &u
+
+	case "fill":
+		var u FillUpdate
+		if err := json.Unmarshal(msg.Msg, &u); err != nil {
+			slog.Warn("bad fill update", "err", err)
+			return true
+		}
+		if !ws.tracksSeries(u.Ticker) {
+			return true
+		}
+		cs.mu.Lock()
+		cs.lastFills[u.OrderID] = ws.now()
+		cs.mu.Unlock()
+		slog.Info("kalshi ws fill",
+			"ticker", u.Ticker,
+			"orderID", u.OrderID,
+			"side", u.Side,
+			"count", u.Count,
+			"yesPrice", u.YesPrice,
+		)
+		ev.Kind, ev.Ticker, ev.Fill = EventFill, u.Ticker, &u
+
+	case "user_order":
+		var o Order
+		if err := json.Unmarshal(msg.Msg, &o); err != nil {
+			slog.Warn("bad user order update", "err", err)
+			return true
+		}
+		if !ws.tracksSeries(o.Ticker) {
+			return true
+		}
+		cs.mu.Lock()
+		cs.orders[o.OrderID] = o
+		cs.mu.Unlock()
+		ev.Kind, ev.Ticker, ev.Order = EventOrder, o.Ticker, &o
+
+	case "market_lifecycle_v2":
+		var lc LifecycleEvent
+		if err := json.Unmarshal(msg.Msg, &lc); err != nil {
+			slog.Warn("bad market lifecycle event", "err", err)
+			return true
+		}
+		if !ws.tracksSeries(lc.Ticker) {
+			return true
+		}
+		if lc.EventType == "determined" && lc.Result != "" {
+			cs.mu.Lock()
+			cs.results[lc.Ticker] = lc.Result
+			cs.mu.Unlock()
+		}
+		slog.Debug("market lifecycle", "ticker", lc.Ticker, "event", lc.EventType, "result", lc.Result)
+		ev.Kind, ev.Ticker, ev.Lifecycle = EventLifecycle, lc.Ticker, &lc
+
+	default:
+		return false
+	}
+
+	ws.bus.publish(ev)
+	return true
+}
+
+// tracksSeries reports whether ticker belongs to one of the configured series.
+func (ws *WSClient) tracksSeries(ticker string) bool {
+	for _, s := range ws.cfg.Series {
+		if strings.HasPrefix(ticker, s+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTicker returns the latest ticker channel update for a market.
+func (ws *WSClient) GetTicker(ticker string) (TickerUpdate, bool) {
+	ws.channels.mu.RLock()
+	defer ws.channels.mu.RUnlock()
+	u, ok := ws.channels.tickers[ticker]
+	return u, ok
+}
+
+// LastTrade returns the most recent public trade seen for a market.
+func (ws *WSClient) LastTrade(ticker string) (TradeUpdate, bool) {
+	ws.channels.mu.RLock()
+	defer ws.channels.mu.RUnlock()
+	u, ok := ws.channels.lastTrades[ticker]
+	return u, ok
+}
+
+// GetOrderUpdate returns the latest pushed state of one of our orders.
+func (ws *WSClient) GetOrderUpdate(orderID string) (*Order, bool) {
+	ws.channels.mu.RLock()
+	defer ws.channels.mu.RUnlock()
+	o, ok := ws.channels.orders[orderID]
+	if !ok {
+		return nil, false
+	}
+	return &o, true
+}
+
+// LastFillTime returns when the most recent fill on orderID arrived, or
+// the zero time if none has.
+func (ws *WSClient) LastFillTime(orderID string) time.Time {
+	ws.channels.mu.RLock()
+	defer ws.channels.mu.RUnlock()
+	return ws.channels.lastFills[orderID]
+}
+
+// MarketResult returns "yes" or "no" once a market has been determined,
+// or "" if no determination has been pushed.
+func (ws *WSClient) MarketResult(ticker string) string {
+	ws.channels.mu.RLock()
+	defer ws.channels.mu.RUnlock()
+	return ws.channels.results[ticker]
+}
+
+// ForgetOrder drops pushed state for an order once it's been handled.
+func (ws *WSClient) ForgetOrder(orderID string) {
+	ws.channels.mu.Lock()
+	delete(ws.channels.orders, orderID)
+	delete(ws.channels.lastFills, orderID)
+	ws.channels.mu.Unlock()
+}
+
+// forgetMarkets drops per-market channel state for unsubscribed tickers.
+func (cs *channelState) forgetMarkets(tickers []string) {
+	cs.mu.Lock()
+	for _, t := range tickers {
+		delete(cs.tickers, t)
+		delete(cs.lastTrades, t)
+		delete(cs.results, t)
+	}
+	cs.mu.Unlock()
+}
@@ -0,0 +1,358 @@
+package kalshi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsCommandTimeout bounds how long a command's result waits for Kalshi to
+// respond.
+const wsCommandTimeout = 10 * time.Second
+
+// errWSClosed fails commands still awaiting a response when the connection drops.
+var errWSClosed = errors.New("kalshi ws connection closed")
+
+// WSCommandError is Kalshi's "error" response to a WS command.
+type WSCommandError struct {
+	ID   int64
+	Cmd  string
+	Code int
+	Msg  string
+}
+
+func (e *WSCommandError) Error() string {
+	return fmt.Sprintf("kalshi ws %s (id %d): %s (code %d)", e.Cmd, e.ID, e.Msg, e.Code)
+}
+
+type wsCommand struct {
+	ID     int64           `json:"id"`
+	Cmd    string          `json:"cmd"`
+	Params wsCommandParams `json:"params"`
+}
+
+type wsCommandParams struct {
+	Channels      []string `json:"channels,omitempty"`
+	MarketTickers []string `json:"market_tickers,omitempty"`
+	Sids          []int64  `json:"sids,omitempty"`
+	Action        string   `json:"action,omitempty"` // update_subscription: "add_markets" or "delete_markets"
+}
+
+// wsSubscription is one server-side subscription: a single channel, and for
+// market channels the tickers it streams.
+type wsSubscription struct {
+	sid     int64
+	channel string
+	tickers map[string]bool // nil for channels not scoped to markets
+}
+
+// wsPending is a command awaiting its responses. A subscribe gets one
+// "subscribed" per channel, an unsubscribe one "unsubscribed" per sid, and
+// an update_subscription a single "ok".
+type wsPending struct {
+	cmd      wsCommand
+	want     int
+	got      int
+	async    bool        // nobody waits; failures are logged
+	done     chan error  // receives the result once, unless async
+	timer    *time.Timer // fails the waiter when Kalshi is slow to respond
+	answered bool        // the result has been delivered
+}
+
+// send writes a command with a fresh ID and registers it for response
+// matching. Unless async, the result arrives on the returned command's done
+// channel: nil once fully acknowledged, Kalshi's error, errWSClosed, or a
+// timeout after wsCommandTimeout. Callers on the read goroutine must pass
+// async or not wait, since the responses are read by that same goroutine.
+func (ws *WSClient) send(conn *websocket.Conn, cmd string, params wsCommandParams, want int, async bool) (*wsPending, error) {
+	ws.cmdMu.Lock()
+	ws.nextCmdID++
+	p := &wsPending{
+		cmd:   wsCommand{ID: ws.nextCmdID, Cmd: cmd, Params: params},
+		want:  want,
+		async: async,
+		done:  make(chan error, 1),
+	}
+	if !async {
+		p.timer = time.AfterFunc(wsCommandTimeout, func() {
+			ws.cmdMu.Lock()
+			defer ws.cmdMu.Unlock()
+			ws.answer(p, fmt.Errorf("kalshi ws %s (id %d): no response after %s", p.cmd.Cmd, p.cmd.ID, wsCommandTimeout))
+		})
+	}
+	ws.pending[p.cmd.ID] = p
+	ws.cmdMu.Unlock()
+
+	ws.writeMu.Lock()
+	err := conn.WriteJSON(p.cmd)
+	ws.writeMu.Unlock()
+	if err != nil {
+		ws.cmdMu.Lock()
+		delete(ws.pending, p.cmd.ID)
+		p.answered = true
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+		ws.cmdMu.Unlock()
+		return nil, fmt.Errorf("kalshi ws %s: %w", cmd, err)
+	}
+	return p, nil
+}
+
+// sendAsync sends a command nobody waits for; failures are logged.
+func (ws *WSClient) sendAsync(conn *websocket.Conn, cmd string, params wsCommandParams, want int) {
+	if _, err := ws.send(conn, cmd, params, want, true); err != nil {
+		slog.Warn("kalshi ws command failed", "err", err)
+	}
+}
+
+// sendRemovals unsubscribes the sids in drop and deletes trim's tickers
+// from the rest, as returned by removeTickers. Unless async, the commands'
+// joined results arrive on the returned channel.
+func (ws *WSClient) sendRemovals(conn *websocket.Conn, drop []int64, trim map[int64][]string, async bool) <-chan error {
+	var sent []*wsPending
+	var errs []error
+	send := func(cmd string, params wsCommandParams, want int) {
+		p, err := ws.send(conn, cmd, params, want, async)
+		if err != nil {
+			if async {
+				slog.Warn("kalshi ws command failed", "err", err)
+			}
+			errs = append(errs, err)
+			return
+		}
+		sent = append(sent, p)
+	}
+	if len(drop) > 0 {
+		send("unsubscribe", wsCommandParams{Sids: drop}, len(drop))
+	}
+	for sid, removed := range trim {
+		send("update_subscription", wsCommandParams{
+			Sids:          []int64{sid},
+			MarketTickers: removed,
+			Action:        "delete_markets",
+		}, 1)
+	}
+	if async {
+		return nil
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		for _, p := range sent {
+			errs = append(errs, <-p.done)
+		}
+		result <- errors.Join(errs...)
+	}()
+	return result
+}
+
+// commandFailed returns a result channel holding err.
+func commandFailed(err error) <-chan error {
+	result := make(chan error, 1)
+	result <- err
+	return result
+}
+
+// resolve finishes p. Caller holds cmdMu.
+func (ws *WSClient) resolve(p *wsPending, err error) {
+	delete(ws.pending, p.cmd.ID)
+	if err != nil && !errors.Is(err, errWSClosed) {
+		ws.cmdErrors.Add(1)
+		if p.answered {
+			slog.Warn("kalshi ws command failed after timing out", "err", err)
+		}
+	}
+	ws.answer(p, err)
+}
+
+// answer delivers p's result, once. A command that timed out stays pending,
+// so a late acknowledgement is still applied. Caller holds cmdMu.
+func (ws *WSClient) answer(p *wsPending, err error) {
+	if p.answered {
+		return
+	}
+	p.answered = true
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	if p.async {
+		if err != nil && !errors.Is(err, errWSClosed) {
+			slog.Warn("kalshi ws command failed", "err", err)
+		}
+		return
+	}
+	p.done <- err
+}
+
+// progress counts one acknowledgement toward p. Caller holds cmdMu.
+func (ws *WSClient) progress(p *wsPending) {
+	if p == nil {
+		return
+	}
+	p.got++
+	if p.got >= p.want {
+		ws.resolve(p, nil)
+	}
+}
+
+// handleCommandResponse processes acknowledgements and errors. It reports
+// false for messages that aren't command responses.
+func (ws *WSClient) handleCommandResponse(msg wsMessage) bool {
+	switch msg.Type {
+	case "subscribed":
+		var body struct {
+			Channel string `json:"channel"`
+			Sid     int64  `json:"sid"`
+		}
+		if err := json.Unmarshal(msg.Msg, &body); err != nil {
+			slog.Warn("bad subscribed response", "err", err)
+			return true
+		}
+		ws.cmdMu.Lock()
+		p := ws.pending[msg.ID]
+		sub := &wsSubscription{sid: body.Sid, channel: body.Channel}
+		if p != nil && len(p.cmd.Params.MarketTickers) > 0 {
+			sub.tickers = make(map[string]bool)
+			for _, t := range p.cmd.Params.MarketTickers {
+				sub.tickers[t] = true
+			}
+		}
+		ws.subs[body.Sid] = sub
+		n := len(sub.tickers)
+		ws.progress(p)
+		ws.cmdMu.Unlock()
+		slog.Debug("kalshi ws subscribed", "channel", body.Channel, "sid", body.Sid, "tickers", n)
+		ws.dropUnwanted(sub)
+
+	case "unsubscribed":
+		ws.cmdMu.Lock()
+		delete(ws.subs, msg.Sid)
+		ws.progress(ws.pending[msg.ID])
+		ws.cmdMu.Unlock()
+
+	case "ok":
+		// An update_subscription ack is numbered in its sid's sequence
+		if msg.Seq != 0 {
+			ws.obMu.Lock()
+			if _, ok := ws.sidSeq[msg.Sid]; ok {
+				ws.sidSeq[msg.Sid] = msg.Seq
+			}
+			ws.obMu.Unlock()
+		}
+		ws.cmdMu.Lock()
+		ws.progress(ws.pending[msg.ID])
+		ws.cmdMu.Unlock()
+
+	case "error":
+		var body struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if err := json.Unmarshal(msg.Msg, &body); err != nil {
+			slog.Warn("bad ws error response", "err", err)
+			return true
+		}
+		ws.cmdMu.Lock()
+		if p := ws.pending[msg.ID]; p != nil {
+			ws.resolve(p, &WSCommandError{ID: msg.ID, Cmd: p.cmd.Cmd, Code: body.Code, Msg: body.Msg})
+		} else {
+			slog.Warn("kalshi ws error", "id", msg.ID, "code", body.Code, "msg", body.Msg)
+		}
+		ws.cmdMu.Unlock()
+
+	default:
+		return false
+	}
+	return true
+}
+
+// removeTickers takes tickers out of every market subscription. It returns
+// the sids left with no markets, which should be unsubscribed, and per
+// remaining sid the tickers to delete from it with update_subscription.
+func (ws *WSClient) removeTickers(tickers []string, channel string) (drop []int64, trim map[int64][]string) {
+	ws.cmdMu.Lock()
+	defer ws.cmdMu.Unlock()
+
+	trim = make(map[int64][]string)
+	for sid, sub := range ws.subs {
+		if sub.tickers == nil || (channel != "" && sub.channel != channel) {
+			continue
+		}
+		var removed []string
+		for _, t := range tickers {
+			if sub.tickers[t] {
+				delete(sub.tickers, t)
+				removed = append(removed, t)
+			}
+		}
+		switch {
+		case len(removed) == 0:
+		case len(sub.tickers) == 0:
+			delete(ws.subs, sid)
+			drop = append(drop, sid)
+		default:
+			trim[sid] = removed
+		}
+	}
+	slices.Sort(drop)
+	return drop, trim
+}
+
+// dropUnwanted removes tickers unsubscribed while sub's subscribe was in
+// flight, which Unsubscribe could not yet find a sid for.
+func (ws *WSClient) dropUnwanted(sub *wsSubscription) {
+	var unwanted []string
+	ws.subMu.RLock()
+	for t := range sub.tickers {
+		if !ws.subscribedTickers[t] {
+			unwanted = append(unwanted, t)
+		}
+	}
+	ws.subMu.RUnlock()
+	if len(unwanted) == 0 {
+		return
+	}
+	slices.Sort(unwanted)
+
+	drop, trim := ws.removeTickers(unwanted, sub.channel)
+	ws.mu.RLock()
+	conn := ws.conn
+	ws.mu.RUnlock()
+	if conn == nil {
+		return
+	}
+	ws.sendRemovals(conn, drop, trim, true)
+}
+
+// dropSubscription forgets sid and returns the tickers it streamed.
+func (ws *WSClient) dropSubscription(sid int64) []string {
+	ws.cmdMu.Lock()
+	defer ws.cmdMu.Unlock()
+	sub := ws.subs[sid]
+	if sub == nil {
+		return nil
+	}
+	delete(ws.subs, sid)
+	tickers := make([]string, 0, len(sub.tickers))
+	for t := range sub.tickers {
+		tickers = append(tickers, t)
+	}
+	return tickers
+}
+
+// resetCommands fails outstanding commands and forgets every sid. Called
+// when a connection ends.
+func (ws *WSClient) resetCommands() {
+	ws.cmdMu.Lock()
+	defer ws.cmdMu.Unlock()
+	for _, p := range ws.pending {
+		ws.resolve(p, errWSClosed)
+	}
+	clear(ws.subs)
+}
@@ -0,0 +1,172 @@
+package kalshi
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsPingInterval is how often we ping Kalshi; wsPongWait is how long the
+	// connection may go without any frame (message, ping or pong) before it
+	// is considered dead.
+	wsPingInterval = 10 * time.Second
+	wsPongWait     = 30 * time.Second
+
+	// wsStableAfter is how long a connection must stay up for the next
+	// reconnect to start again from the shortest backoff.
+	wsStableAfter = time.Minute
+
+	// wsMinReconnectDelay keeps full-jitter backoff from redialing instantly.
+	wsMinReconnectDelay = 250 * time.Millisecond
+)
+
+// wsReconnectPolicy is the exponential backoff between reconnect attempts.
+var wsReconnectPolicy = retryPolicy{
+	BaseDelay: time.Second,
+	MaxDelay:  time.Minute,
+}
+
+// WSHealth is a point-in-time view of the feed.
+type WSHealth struct {
+	Connected      bool
+	ConnectedSince time.Time // zero while disconnected
+	LastMessage    time.Time
+	LastHeard      time.Time // last message, ping or pong; see WSClient.LastHeard
+	Reconnects     int
+	SeqGaps        int64
+	CommandErrors  int64 // subscription commands Kalshi rejected
+
+	// BookAge is the time since each book's last snapshot or delta. Stale
+	// books (awaiting a resnapshot) are listed in StaleBooks instead.
+	BookAge    map[string]time.Duration
+	StaleBooks []string
+}
+
+// wsHealthState tracks connection liveness for Health.
+type wsHealthState struct {
+	mu          sync.Mutex
+	connectedAt time.Time
+	lastMessage time.Time
+	lastHeard   time.Time
+	reconnects  int
+}
+
+func (h *wsHealthState) connected() {
+	h.mu.Lock()
+	h.connectedAt = time.Now()
+	h.mu.Unlock()
+}
+
+func (h *wsHealthState) disconnected() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var up time.Duration
+	if !h.connectedAt.IsZero() {
+		up = time.Since(h.connectedAt)
+	}
+	h.connectedAt = time.Time{}
+	return up
+}
+
+func (h *wsHealthState) received() {
+	h.mu.Lock()
+	h.lastMessage = time.Now()
+	h.lastHeard = h.lastMessage
+	h.mu.Unlock()
+}
+
+// heard records a ping or pong from Kalshi.
+func (h *wsHealthState) heard() {
+	h.mu.Lock()
+	h.lastHeard = time.Now()
+	h.mu.Unlock()
+}
+
+func (h *wsHealthState) reconnecting() {
+	h.mu.Lock()
+	h.reconnects++
+	h.mu.Unlock()
+}
+
+// Health reports connection state, reconnects and the age of every book.
+func (ws *WSClient) Health() WSHealth {
+	ws.health.mu.Lock()
+	h := WSHealth{
+		Connected:      !ws.health.connectedAt.IsZero(),
+		ConnectedSince: ws.health.connectedAt,
+		LastMessage:    ws.health.lastMessage,
+		LastHeard:      ws.health.lastHeard,
+		Reconnects:     ws.health.reconnects,
+		SeqGaps:        ws.SeqGaps(),
+		CommandErrors:  ws.cmdErrors.Load(),
+		BookAge:        make(map[string]time.Duration),
+	}
+	ws.health.mu.Unlock()
+
+	ws.obMu.RLock()
+	for t, ob := range ws.orderbooks {
+		if ob.Stale {
+			h.StaleBooks = append(h.StaleBooks, t)
+			continue
+		}
+		h.BookAge[t] = time.Since(ob.LastUpdate)
+	}
+	ws.obMu.RUnlock()
+	return h
+}
+
+// LastHeard returns when anything, including a ping or pong, last arrived
+// on the connection. Kalshi answers our pings every wsPingInterval, so on a
+// live connection it is never much older than that, even when no market is
+// trading; zero before the first connection.
+func (ws *WSClient) LastHeard() time.Time {
+	ws.health.mu.Lock()
+	defer ws.health.mu.Unlock()
+	return ws.health.lastHeard
+}
+
+// keepalive extends the read deadline on every ping or pong, pings Kalshi
+// every wsPingInterval, and closes conn when ctx ends so a blocked read
+// returns. It runs until done is closed.
+func (ws *WSClient) keepalive(ctx context.Context, conn *websocket.Conn, done <-chan struct{}) {
+	extend := func(string) error {
+		ws.health.heard()
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	}
+	conn.SetPongHandler(extend)
+	conn.SetPingHandler(func(data string) error {
+		extend(data)
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+					slog.Debug("kalshi ws ping failed", "err", err)
+				}
+			}
+		}
+	}()
+}
+
+// reconnectDelay returns the backoff before reconnect attempt (0-based).
+func reconnectDelay(attempt int) time.Duration {
+	return max(wsReconnectPolicy.backoff(attempt, 0), wsMinReconnectDelay)
+}
@@ -0,0 +1,316 @@
+package kalshi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+)
+
+func newTestWSClient(t *testing.T, tickers ...string) *WSClient {
+	t.Helper()
+	ws, err := NewWSClient(&config.Config{}, nil)
+	if err != nil {
+		t.Fatalf("NewWSClient: %v", err)
+	}
+	if err := ws.Subscribe(tickers); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	return ws
+}
+
+const testSnapshot = `{"type":"orderbook_snapshot","sid":1,"seq":1,"msg":{"market_ticker":"T1","yes":[[40,10]],"no":[[55,5]]}}`
+
+func TestDeltaInSequenceApplies(t *testing.T) {
+	ws := newTestWSClient(t, "T1")
+	ws.handleMessage([]byte(testSnapshot))
+	ws.handleMessage([]byte(`{"type":"orderbook_delta","sid":1,"seq":2,"msg":{"market_ticker":"T1","price":42,"delta":3,"side":"yes"}}`))
+
+	ob := ws.GetOrderbook("T1")
+	if ob == nil {
+		t.Fatal("book missing")
+	}
+	if ob.BestYesBid() != 42 {
+		t.Errorf("best yes bid = %d, want 42", ob.BestYesBid())
+	}
+	if ws.SeqGaps() != 0 {
+		t.Errorf("gaps = %d, want 0", ws.SeqGaps())
+	}
+}
+
+func TestSeqGapInvalidatesBook(t *testing.T) {
+	ws := newTestWSClient(t, "T1")
+	ws.handleMessage([]byte(testSnapshot))
+	ws.handleMessage([]byte(`{"type":"orderbook_delta","sid":1,"seq":3,"msg":{"market_ticker":"T1","price":42,"delta":3,"side":"yes"}}`))
+
+	if ob := ws.GetOrderbook("T1"); ob != nil {
+		t.Fatalf("book = %+v, want nil after gap", ob)
+	}
+	if ws.SeqGaps() != 1 {
+		t.Errorf("gaps = %d, want 1", ws.SeqGaps())
+	}
+
+	// Late messages on the abandoned sid are ignored
+	ws.handleMessage([]byte(`{"type":"orderbook_delta","sid":1,"seq":4,"msg":{"market_ticker":"T1","price":43,"delta":1,"side":"yes"}}`))
+	if ws.GetOrderbook("T1") != nil || ws.SeqGaps() != 1 {
+		t.Fatal("abandoned sid should be ignored")
+	}
+
+	// A snapshot on the new subscription restores the book
+	ws.handleMessage([]byte(`{"type":"orderbook_snapshot","sid":2,"seq":1,"msg":{"market_ticker":"T1","yes":[[41,2]],"no":[]}}`))
+	ob := ws.GetOrderbook("T1")
+	if ob == nil || ob.BestYesBid() != 41 {
+		t.Fatalf("book = %+v, want fresh snapshot", ob)
+	}
+}
+
+func TestDeltaForUnknownLevelInvalidatesBook(t *testing.T) {
+	ws := newTestWSClient(t, "T1")
+	ws.handleMessage([]byte(testSnapshot))
+	ws.handleMessage([]byte(`{"type":"orderbook_delta","sid":1,"seq":2,"msg":{"market_ticker":"T1","price":30,"delta":-4,"side":"yes"}}`))
+
+	if ws.GetOrderbook("T1") != nil {
+		t.Fatal("book should be stale after delta for unknown level")
+	}
+	if ws.SeqGaps() != 1 {
+		t.Errorf("gaps = %d, want 1", ws.SeqGaps())
+	}
+}
+
+func TestChannelMessages(t *testing.T) {
+	ws, err := NewWSClient(&config.Config{Series: []string{"KXBTC15M"}}, nil)
+	if err != nil {
+		t.Fatalf("NewWSClient: %v", err)
+	}
+
+	lifecycle := ws.SubscribeEvents(EventFilter{Kinds: []EventKind{EventLifecycle}})
+	defer lifecycle.Close()
+
+	ws.handleMessage([]byte(`{"type":"ticker","sid":3,"msg":{"market_ticker":"KXBTC15M-A","price":61,"yes_bid":60,"yes_ask":62,"volume":100}}`))
+	if u, ok := ws.GetTicker("KXBTC15M-A"); !ok || u.YesAsk != 62 {
+		t.Errorf("ticker = %+v, %v", u, ok)
+	}
+
+	ws.handleMessage([]byte(`{"type":"user_order","sid":4,"msg":{"order_id":"o1","ticker":"KXBTC15M-A","status":"executed","fill_count":5}}`))
+	if o, ok := ws.GetOrderUpdate("o1"); !ok || !o.IsFinal() || o.FilledCount != 5 {
+		t.Errorf("order = %+v, %v", o, ok)
+	}
+
+	ws.handleMessage([]byte(`{"type":"fill","sid":5,"msg":{"order_id":"o1","market_ticker":"KXBTC15M-A","count":5}}`))
+	if ws.LastFillTime("o1").IsZero() {
+		t.Error("fill not recorded")
+	}
+
+	ws.handleMessage([]byte(`{"type":"market_lifecycle_v2","sid":6,"msg":{"market_ticker":"KXETH15M-B","event_type":"created"}}`))
+	ws.handleMessage([]byte(`{"type":"market_lifecycle_v2","sid":6,"msg":{"market_ticker":"KXBTC15M-A","event_type":"determined","result":"yes"}}`))
+	select {
+	case ev := <-lifecycle.C:
+		if ev.Ticker != "KXBTC15M-A" || ev.Lifecycle.Result != "yes" {
+			t.Errorf("event = %+v, want only the configured series", ev)
+		}
+	default:
+		t.Fatal("no lifecycle event published")
+	}
+	if len(lifecycle.C) != 0 {
+		t.Errorf("%d extra lifecycle events, want other series filtered", len(lifecycle.C))
+	}
+	if ws.MarketResult("KXBTC15M-A") != "yes" {
+		t.Errorf("result = %q, want yes", ws.MarketResult("KXBTC15M-A"))
+	}
+}
+
+// fakeWSServer acknowledges commands the way Kalshi does, handing out a
+// new sid per subscribed channel, and records every command it receives.
+// Subscribing ticker "BAD" gets an error response.
+func fakeWSServer(t *testing.T) (*httptest.Server, chan wsCommand) {
+	t.Helper()
+	cmds := make(chan wsCommand, 16)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var sid int64
+		for {
+			var cmd wsCommand
+			if err := conn.ReadJSON(&cmd); err != nil {
+				return
+			}
+			cmds <- cmd
+			switch {
+			case slices.Contains(cmd.Params.MarketTickers, "QUIET"):
+				// left for the test to acknowledge
+			case slices.Contains(cmd.Params.MarketTickers, "BAD"):
+				conn.WriteJSON(map[string]any{"id": cmd.ID, "type": "error", "msg": map[string]any{"code": 8, "msg": "Unknown market"}})
+			case cmd.Cmd == "subscribe":
+				for _, ch := range cmd.Params.Channels {
+					sid++
+					conn.WriteJSON(map[string]any{"id": cmd.ID, "type": "subscribed", "msg": map[string]any{"channel": ch, "sid": sid}})
+				}
+			case cmd.Cmd == "unsubscribe":
+				for _, s := range cmd.Params.Sids {
+					conn.WriteJSON(map[string]any{"id": cmd.ID, "sid": s, "type": "unsubscribed"})
+				}
+			case cmd.Cmd == "update_subscription":
+				conn.WriteJSON(map[string]any{"id": cmd.ID, "sid": cmd.Params.Sids[0], "type": "ok", "msg": map[string]any{}})
+			}
+		}
+	}))
+	return srv, cmds
+}
+
+// attachConn dials srv and runs the read loop, standing in for connect.
+func attachConn(t *testing.T, ws *WSClient, srv *httptest.Server) {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ws.mu.Lock()
+	ws.conn = conn
+	ws.mu.Unlock()
+	go func() {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			ws.handleMessage(msg)
+		}
+	}()
+}
+
+func TestSubscribeAndUnsubscribeCommands(t *testing.T) {
+	srv, cmds := fakeWSServer(t)
+	defer srv.Close()
+	ws := newTestWSClient(t)
+	attachConn(t, ws, srv)
+
+	if err := ws.Subscribe([]string{"T1", "T2"}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := ws.Subscribe([]string{"T3"}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	first, second := <-cmds, <-cmds
+	if first.ID == second.ID {
+		t.Errorf("command IDs not unique: %d", first.ID)
+	}
+
+	// T3 is alone on its sids: unsubscribe them. T1 shares with T2: trim.
+	if err := ws.Unsubscribe([]string{"T1", "T3"}); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	var unsub, updates int
+	for range 1 + len(marketChannels) {
+		cmd := <-cmds
+		switch cmd.Cmd {
+		case "unsubscribe":
+			unsub++
+			if len(cmd.Params.Sids) != len(marketChannels) {
+				t.Errorf("unsubscribe sids = %v", cmd.Params.Sids)
+			}
+		case "update_subscription":
+			updates++
+			if cmd.Params.Action != "delete_markets" || !slices.Equal(cmd.Params.MarketTickers, []string{"T1"}) {
+				t.Errorf("update_subscription params = %+v", cmd.Params)
+			}
+		}
+	}
+	if unsub != 1 || updates != len(marketChannels) {
+		t.Errorf("got %d unsubscribe, %d update_subscription", unsub, updates)
+	}
+}
+
+func TestSubscribeReturnsCommandError(t *testing.T) {
+	srv, _ := fakeWSServer(t)
+	defer srv.Close()
+	ws := newTestWSClient(t)
+	attachConn(t, ws, srv)
+
+	err := ws.Subscribe([]string{"BAD"})
+	var cmdErr *WSCommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != 8 {
+		t.Fatalf("err = %v, want WSCommandError code 8", err)
+	}
+	if n := ws.Health().CommandErrors; n != 1 {
+		t.Errorf("CommandErrors = %d, want 1", n)
+	}
+}
+
+func TestSubscribeAsyncReportsAck(t *testing.T) {
+	srv, cmds := fakeWSServer(t)
+	defer srv.Close()
+	ws := newTestWSClient(t)
+	attachConn(t, ws, srv)
+
+	result := ws.SubscribeAsync([]string{"QUIET"})
+	sub := <-cmds
+	for i, ch := range marketChannels {
+		select {
+		case err := <-result:
+			t.Fatalf("result %v before every channel was acknowledged", err)
+		default:
+		}
+		ws.handleMessage([]byte(fmt.Sprintf(`{"id":%d,"type":"subscribed","msg":{"channel":%q,"sid":%d}}`, sub.ID, ch, 90+i)))
+	}
+	if err := <-result; err != nil {
+		t.Errorf("result = %v, want nil", err)
+	}
+}
+
+func TestUnsubscribeBeforeAckDropsSubscription(t *testing.T) {
+	srv, cmds := fakeWSServer(t)
+	defer srv.Close()
+	ws := newTestWSClient(t)
+	attachConn(t, ws, srv)
+
+	ws.SubscribeAsync([]string{"QUIET"})
+	sub := <-cmds
+	if err := ws.Unsubscribe([]string{"QUIET"}); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	// The ack arrives after the market was unsubscribed
+	ws.handleMessage([]byte(fmt.Sprintf(`{"id":%d,"type":"subscribed","msg":{"channel":"orderbook_delta","sid":99}}`, sub.ID)))
+	cmd := <-cmds
+	if cmd.Cmd != "unsubscribe" || !slices.Equal(cmd.Params.Sids, []int64{99}) {
+		t.Errorf("command = %+v, want unsubscribe of sid 99", cmd)
+	}
+}
+
+func TestHealthReportsBookAges(t *testing.T) {
+	ws := newTestWSClient(t, "T1", "T2")
+	ws.handleMessage([]byte(testSnapshot))
+	ws.handleMessage([]byte(`{"type":"orderbook_snapshot","sid":2,"seq":1,"msg":{"market_ticker":"T2","yes":[[30,1]],"no":[]}}`))
+	ws.handleMessage([]byte(`{"type":"orderbook_delta","sid":2,"seq":5,"msg":{"market_ticker":"T2","price":31,"delta":1,"side":"yes"}}`))
+
+	h := ws.Health()
+	if h.Connected {
+		t.Error("Connected = true with no connection")
+	}
+	if _, ok := h.BookAge["T1"]; !ok {
+		t.Errorf("BookAge = %v, want T1", h.BookAge)
+	}
+	if len(h.StaleBooks) != 1 || h.StaleBooks[0] != "T2" || h.SeqGaps != 1 {
+		t.Errorf("StaleBooks = %v, SeqGaps = %d, want [T2], 1", h.StaleBooks, h.SeqGaps)
+	}
+}
+
+func TestReconnectDelayBounds(t *testing.T) {
+	for attempt := range 12 {
+		d := reconnectDelay(attempt)
+		if d < wsMinReconnectDelay || d > wsReconnectPolicy.MaxDelay {
+			t.Errorf("attempt %d: delay %s out of bounds", attempt, d)
+		}
+	}
+}
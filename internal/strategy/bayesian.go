@@ -141,6 +141,26 @@ func (bp *BayesianPosterior) Percentile5() float64 {
 	return p5
 }
 
+// Quantile returns the q-th quantile of the posterior (0 < q < 1), using
+// the same normal approximation to Beta as Percentile5. Quantile(0.05)
+// matches Percentile5 up to rounding of z.
+func (bp *BayesianPosterior) Quantile(q float64) float64 {
+	bp.mu.Lock()
+	a := float64(bp.Alpha)
+	b := float64(bp.Beta)
+	bp.mu.Unlock()
+
+	if a+b == 0 {
+		return 0.5
+	}
+
+	mean := a / (a + b)
+	variance := (a * b) / ((a + b) * (a + b) * (a + b + 1))
+	z := math.Sqrt2 * math.Erfinv(2*q-1)
+
+	return math.Min(1, math.Max(0, mean+z*math.Sqrt(variance)))
+}
+
 // CredibleInterval returns the [lower, upper] Bayesian credible interval.
 // Uses simple quantile approximation based on normal approximation to Beta.
 func (bp *BayesianPosterior) CredibleInterval(confidence float64) [2]float64 {
@@ -0,0 +1,122 @@
+package strategy
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/journal"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// MarketDataSource supplies exchange and market metadata, live books and
+// pushed market events.
+type MarketDataSource interface {
+	GetExchangeStatus(ctx context.Context) (*kalshi.ExchangeStatus, error)
+	GetExchangeSchedule(ctx context.Context) (*kalshi.ExchangeSchedule, error)
+	GetSeries(ctx context.Context, seriesTicker string) (*kalshi.Series, error)
+	GetMarkets(ctx context.Context, seriesTicker, status string) ([]kalshi.Market, error)
+	GetMarket(ctx context.Context, ticker string) (*kalshi.Market, error)
+
+	// SubscribeAsync and UnsubscribeAsync start and stop streaming a
+	// market's book. They return at once; the exchange's answer (nil when
+	// acknowledged) arrives on the channel.
+	SubscribeAsync(tickers []string) <-chan error
+	UnsubscribeAsync(tickers []string) <-chan error
+	// GetOrderbook returns a copy of the streamed book, or nil if none yet.
+	GetOrderbook(ticker string) *kalshi.OrderbookState
+	// LastHeard returns when the stream last heard from the exchange,
+	// including keepalives.
+	LastHeard() time.Time
+	// MarketResult returns a pushed determination ("yes"/"no"), or "".
+	MarketResult(ticker string) string
+	// Events streams pushed events matching filter until stop is called.
+	Events(filter kalshi.EventFilter) (events <-chan kalshi.StreamEvent, stop func())
+}
+
+// OrderGateway places, cancels and tracks orders.
+type OrderGateway interface {
+	CreateOrder(ctx context.Context, req kalshi.OrderRequest) (*kalshi.Order, error)
+	GetOrder(ctx context.Context, orderID string) (*kalshi.Order, error)
+	GetOrderByClientID(ctx context.Context, ticker, clientOrderID string) (*kalshi.Order, error)
+	CancelOrder(ctx context.Context, orderID string) (*kalshi.Order, error)
+
+	// GetOrderUpdate returns the latest pushed state of an order.
+	GetOrderUpdate(orderID string) (*kalshi.Order, bool)
+	// LastFillTime returns when a fill for the order was last pushed.
+	LastFillTime(orderID string) time.Time
+	// ForgetOrder drops pushed state for an order the engine is done with.
+	ForgetOrder(orderID string)
+}
+
+// PortfolioReader reads the account: balance, positions, fills and
+// settlement records.
+type PortfolioReader interface {
+	GetBalance(ctx context.Context) (*kalshi.Balance, error)
+	GetPositions(ctx context.Context, eventTicker string) ([]kalshi.Position, error)
+	GetFills(ctx context.Context, params url.Values) ([]kalshi.Fill, error)
+	GetSettlements(ctx context.Context, params url.Values) ([]kalshi.Settlement, error)
+}
+
+// EventSink records journal events (trades, settlements, status changes).
+type EventSink interface {
+	Log(event any) error
+}
+
+// Deps are the engine's connections to the exchange and the journal.
+type Deps struct {
+	Market    MarketDataSource
+	Orders    OrderGateway
+	Portfolio PortfolioReader
+	Journal   EventSink
+}
+
+// LiveDeps wires the engine to a REST client, its WS feed and a journal.
+func LiveDeps(client *kalshi.Client, ws *kalshi.WSClient, j *journal.Journal) Deps {
+	return Deps{
+		Market:    liveMarketData{client, ws},
+		Orders:    liveOrders{client, ws},
+		Portfolio: client,
+		Journal:   j,
+	}
+}
+
+// liveMarketData serves metadata over REST and books and events over WS.
+type liveMarketData struct {
+	*kalshi.Client
+	ws *kalshi.WSClient
+}
+
+func (d liveMarketData) SubscribeAsync(tickers []string) <-chan error {
+	return d.ws.SubscribeAsync(tickers)
+}
+
+func (d liveMarketData) UnsubscribeAsync(tickers []string) <-chan error {
+	return d.ws.UnsubscribeAsync(tickers)
+}
+
+// GetOrderbook shadows the REST snapshot with the streamed book.
+func (d liveMarketData) GetOrderbook(ticker string) *kalshi.OrderbookState {
+	return d.ws.GetOrderbook(ticker)
+}
+
+func (d liveMarketData) MarketResult(ticker string) string { return d.ws.MarketResult(ticker) }
+func (d liveMarketData) LastHeard() time.Time              { return d.ws.LastHeard() }
+
+func (d liveMarketData) Events(filter kalshi.EventFilter) (<-chan kalshi.StreamEvent, func()) {
+	sub := d.ws.SubscribeEvents(filter)
+	return sub.C, sub.Close
+}
+
+// liveOrders places orders over REST and tracks them with WS pushes.
+type liveOrders struct {
+	*kalshi.Client
+	ws *kalshi.WSClient
+}
+
+func (o liveOrders) GetOrderUpdate(orderID string) (*kalshi.Order, bool) {
+	return o.ws.GetOrderUpdate(orderID)
+}
+
+func (o liveOrders) LastFillTime(orderID string) time.Time { return o.ws.LastFillTime(orderID) }
+func (o liveOrders) ForgetOrder(orderID string)            { o.ws.ForgetOrder(orderID) }
@@ -0,0 +1,48 @@
+package strategy
+
+import "github.com/sdibella/kalshi-btc15m/internal/kalshi"
+
+// DepthSize sizes a taker buy of side by walking the asks, best first,
+// with win rate p. Each level's contracts are taken while
+//
+//   - the marginal edge of one more contract there is positive
+//     (see MarginalEdge), and
+//   - the running total stays within quarter-Kelly at that level's price,
+//     which shrinks as the price worsens. Unlike KellySizeAt, the Kelly
+//     fee here is the one MarginalEdge charges (kalshi.MarginalTakerFee),
+//     so both limits price a contract the same way.
+//
+// Levels more than maxSlippage cents above the best ask are left alone; a
+// negative maxSlippage walks the whole book. A limit at the worst level
+// taken sweeps every level before it (see kalshi.OrderbookState.SweepCost).
+func DepthSize(ob *kalshi.OrderbookState, side string, p float64, balanceCents, maxSlippage int) int {
+	contracts, best := 0, 0
+	for l := range ob.Asks(side) {
+		if best == 0 {
+			best = l.Price
+		}
+		if maxSlippage >= 0 && l.Price > best+maxSlippage {
+			break
+		}
+		if MarginalEdge(p, l.Price) <= 0 {
+			break
+		}
+		n := min(l.Quantity, depthKelly(p, l.Price, balanceCents)-contracts)
+		if n <= 0 {
+			break
+		}
+		contracts += n
+	}
+	return contracts
+}
+
+// depthKelly is the quarter-Kelly cap DepthSize applies at price.
+func depthKelly(p float64, price, balanceCents int) int {
+	return kellySize(p, price, balanceCents, kalshi.MarginalTakerFee(price))
+}
+
+// MarginalEdge returns the expected profit in cents of buying one more
+// contract at price with win rate p, net of its taker fee.
+func MarginalEdge(p float64, price int) float64 {
+	return 100*p - float64(price) - kalshi.MarginalTakerFee(price)
+}
@@ -0,0 +1,46 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+func TestDepthSize(t *testing.T) {
+	// YES asks: 50 @ 85, 100 @ 86, 500 @ 90
+	ob, err := kalshi.NewOrderbook("T", []kalshi.PriceLevel{{Price: 80, Quantity: 10}},
+		[]kalshi.PriceLevel{{Price: 10, Quantity: 500}, {Price: 14, Quantity: 100}, {Price: 15, Quantity: 50}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		p           float64
+		balance     int
+		maxSlippage int
+		want        int
+	}{
+		{"top level only", 0.92, 100_000, 0, 50},
+		{"kelly caps the second level", 0.92, 100_000, 2, depthKelly(0.92, 86, 100_000)},
+		{"small balance stays on top", 0.92, 10_000, -1, depthKelly(0.92, 85, 10_000)},
+		{"negative edge at 90 stops the walk", 0.90, 10_000_000, -1, 150},
+		{"no edge at the top", 0.85, 100_000, -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DepthSize(ob, "yes", tt.p, tt.balance, tt.maxSlippage); got != tt.want {
+				t.Errorf("DepthSize = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarginalEdge(t *testing.T) {
+	if got := MarginalEdge(0.92, 85); got <= 0 || got >= 7 {
+		t.Errorf("MarginalEdge(0.92, 85) = %.3f, want 7c less the fee", got)
+	}
+	if got := MarginalEdge(0.92, 92); got >= 0 {
+		t.Errorf("MarginalEdge(0.92, 92) = %.3f, want negative after fees", got)
+	}
+}
@@ -0,0 +1,174 @@
+package strategy
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+	"github.com/sdibella/kalshi-btc15m/internal/journal"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi/kalshitest"
+)
+
+const e2eTicker = "KXBTC15M-26OCT161200-00"
+
+// e2eHarness runs a live Engine and WSClient against a fake exchange.
+type e2eHarness struct {
+	journalPath string
+}
+
+// startEngine runs a live (not dry-run) engine and its WS feed against
+// ex until the test ends.
+func startEngine(t *testing.T, ex *kalshitest.Exchange) *e2eHarness {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	ex.AddKey("e2e", &key.PublicKey)
+	signer := kalshi.NewKeySigner("e2e", key)
+
+	cfg := &config.Config{
+		KalshiAPIKeyID: "e2e",
+		Series:         []string{"KXBTC15M"},
+		KalshiBaseURL:  ex.URL,
+		KalshiWSURL:    ex.WSURL,
+		VolDataDir:     t.TempDir(),
+		VolMaxStdDev:   200,
+		MaxBookAge:     time.Minute,
+	}
+	client, err := kalshi.NewClient(cfg, signer)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ws, err := kalshi.NewWSClient(cfg, signer)
+	if err != nil {
+		t.Fatalf("NewWSClient: %v", err)
+	}
+
+	h := &e2eHarness{journalPath: filepath.Join(t.TempDir(), "journal.jsonl")}
+	j, err := journal.New(h.journalPath)
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+
+	engine, err := NewEngine(LiveDeps(client, ws, j), cfg)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{}, 2)
+	go func() { ws.Run(ctx); done <- struct{}{} }()
+	go func() { engine.Run(ctx); done <- struct{}{} }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		<-done
+		j.Close()
+	})
+	return h
+}
+
+// waitJournal waits for a journal line of type typ and decodes it into v.
+func (h *e2eHarness) waitJournal(t *testing.T, typ string, v any) {
+	t.Helper()
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		if f, err := os.Open(h.journalPath); err == nil {
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				var head struct{ Type string }
+				if json.Unmarshal(sc.Bytes(), &head) == nil && head.Type == typ {
+					f.Close()
+					if err := json.Unmarshal(sc.Bytes(), v); err != nil {
+						t.Fatalf("decoding %s: %v", typ, err)
+					}
+					return
+				}
+			}
+			f.Close()
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("no %s journaled", typ)
+}
+
+func TestEngineEntersOnSignal(t *testing.T) {
+	ex := kalshitest.New()
+	defer ex.Close()
+	ex.SetBalance(100_000)
+
+	// Inside the entry window from the first tick
+	ex.AddMarket(kalshi.Market{
+		Ticker:      e2eTicker,
+		CloseTime:   time.Now().Add((entryWindowStart - 5) * time.Second).UTC().Format(time.RFC3339),
+		StrikeType:  "greater_or_equal",
+		FloorStrike: 68000,
+	})
+	// YES bid 82, YES ask 85: a YES signal at 85
+	ex.SetBook(e2eTicker, []kalshi.PriceLevel{{Price: 82, Quantity: 100}}, []kalshi.PriceLevel{{Price: 15, Quantity: 500}})
+
+	h := startEngine(t, ex)
+
+	var trade journal.Trade
+	h.waitJournal(t, "trade", &trade)
+
+	want := KellySize(85, 100_000)
+	if trade.Ticker != e2eTicker || trade.Side != "yes" || trade.Price != 85 || trade.Filled != want || trade.DryRun {
+		t.Errorf("trade = %+v, want %d YES @ 85", trade, want)
+	}
+	orders := ex.Orders()
+	if len(orders) != 1 || orders[0].FilledCount != want || orders[0].ClientOrderID != ClientOrderID(e2eTicker, "buy", "yes", 85) {
+		t.Fatalf("exchange orders = %+v", orders)
+	}
+	if trade.FeeCents != orders[0].TakerFees || trade.OrderID != orders[0].OrderID {
+		t.Errorf("journaled fee %d / order %s, exchange charged %d on %s",
+			trade.FeeCents, trade.OrderID, orders[0].TakerFees, orders[0].OrderID)
+	}
+}
+
+func TestEngineSettlesReconciledPosition(t *testing.T) {
+	ex := kalshitest.New()
+	defer ex.Close()
+	ex.SetBalance(100_000)
+
+	// Closed a minute ago, holding 20 YES from before the engine started
+	ex.AddMarket(kalshi.Market{
+		Ticker:      e2eTicker,
+		CloseTime:   time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+		StrikeType:  "greater_or_equal",
+		FloorStrike: 68000,
+	})
+	if _, err := ex.Buy(e2eTicker, "yes", 20, 88); err != nil {
+		t.Fatalf("Buy: %v", err)
+	}
+
+	h := startEngine(t, ex)
+	// Give reconciliation a moment to pick up the open position
+	time.Sleep(1500 * time.Millisecond)
+	if err := ex.Settle(e2eTicker, "yes"); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+
+	var st journal.Settlement
+	h.waitJournal(t, "settlement", &st)
+
+	records := ex.Settlements()
+	if len(records) != 1 {
+		t.Fatalf("exchange settlements = %+v", records)
+	}
+	if st.PnLSource != "exchange" || st.PnLCents != records[0].PnL() || !st.Won || st.EntryPrice != 88 || st.Contracts != 20 {
+		t.Errorf("settlement = %+v, want exchange P&L %d", st, records[0].PnL())
+	}
+	if st.PnLMismatch {
+		t.Errorf("local P&L %d disagrees with exchange %d", st.LocalPnLCents, st.PnLCents)
+	}
+}
@@ -0,0 +1,91 @@
+package strategy
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/journal"
+)
+
+// updateTradingGate polls exchange status (every 10s) and schedule (hourly)
+// and flips e.halted on transitions. Errors leave the gate unchanged — a
+// flaky status endpoint shouldn't stop an otherwise healthy exchange.
+func (e *Engine) updateTradingGate(ctx context.Context) {
+	if time.Since(e.lastSchedulePoll) > time.Hour {
+		if sched, err := e.market.GetExchangeSchedule(ctx); err == nil {
+			e.schedule = sched
+			e.lastSchedulePoll = time.Now()
+		} else {
+			slog.Warn("exchange schedule fetch failed", "err", err)
+		}
+	}
+
+	if time.Since(e.lastStatusPoll) < 10*time.Second {
+		return
+	}
+	e.lastStatusPoll = time.Now()
+
+	status, err := e.market.GetExchangeStatus(ctx)
+	if err != nil {
+		slog.Warn("exchange status check failed", "err", err)
+		return
+	}
+
+	active := status.ExchangeActive && status.TradingActive
+	reason := "trading active"
+	switch {
+	case !status.ExchangeActive:
+		reason = "exchange inactive"
+	case !status.TradingActive:
+		reason = "trading paused"
+	}
+	if active && e.schedule != nil {
+		if w := e.schedule.MaintenanceAt(time.Now()); w != nil {
+			active = false
+			reason = "scheduled maintenance until " + w.EndDatetime
+		}
+	}
+
+	if active == !e.halted {
+		return
+	}
+
+	if active {
+		e.resumeTrading()
+	} else {
+		e.halted = true
+		slog.Warn("trading halted — suspending discovery and entries",
+			"reason", reason,
+			"resumeEstimate", status.ExchangeEstimatedResumeTime,
+		)
+	}
+
+	if err := e.journal.Log(journal.NewExchangeStatus(active, reason, status.ExchangeEstimatedResumeTime)); err != nil {
+		slog.Error("failed to journal exchange status", "err", err)
+	}
+}
+
+// resumeTrading lifts the halt. Markets whose entry window opened while we
+// were halted are skipped: their books are stale and entering late would
+// trade outside the backtested window. Discovery runs on the next tick.
+func (e *Engine) resumeTrading() {
+	e.halted = false
+	e.lastDiscovery = time.Time{}
+
+	skipped := 0
+	e.mu.Lock()
+	for _, ms := range e.markets {
+		series := e.seriesFor(ms.Ticker)
+		if ms.Evaluated || ms.Traded || series == nil {
+			continue
+		}
+		if time.Until(ms.CloseTime) <= series.strategy.Window().Open {
+			ms.Evaluated = true
+			skipped++
+		}
+	}
+	e.mu.Unlock()
+
+	slog.Info("trading resumed", "skippedMarkets", skipped)
+}
@@ -0,0 +1,326 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+	"github.com/sdibella/kalshi-btc15m/internal/journal"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// Exit reasons, as journaled on sell trades.
+const (
+	ExitBookFlip    = "book_flip"
+	ExitStrikeCross = "strike_cross"
+	ExitDrawdown    = "drawdown"
+)
+
+// exitCutoff is how long before close the engine stops trying to exit.
+// It leaves room for an exit placed at the cutoff to time out, be
+// canceled and be retried against a fresh book before trading ends.
+const exitCutoff = 15 * time.Second
+
+// exitOrderTimeout is how long an exit order may work before its remainder
+// is canceled and the rules are rechecked against a fresh book.
+const exitOrderTimeout = 5 * time.Second
+
+// exitResolveWait is how long after close settlement waits for a pending
+// exit to resolve before giving up on it and settling the position as last
+// known.
+const exitResolveWait = 2 * time.Minute
+
+// ExitRules close an open position before settlement. A zero field turns
+// its rule off.
+type ExitRules struct {
+	OnFlip      bool    // the book favors the other side (our microprice < 50c)
+	StrikeCross float64 // the index is this many dollars on the losing side of the strike
+	MaxDrawdown float64 // our bid is this fraction of the entry price below entry
+}
+
+// NewExitRules returns the exit rules configured in cfg.
+func NewExitRules(cfg *config.Config) ExitRules {
+	return ExitRules{
+		OnFlip:      cfg.ExitOnFlip,
+		StrikeCross: cfg.ExitStrikeCross,
+		MaxDrawdown: cfg.ExitMaxDrawdown,
+	}
+}
+
+// Enabled reports whether any rule is on.
+func (r ExitRules) Enabled() bool {
+	return r.OnFlip || r.StrikeCross > 0 || r.MaxDrawdown > 0
+}
+
+// Check returns the first rule the position in ms breaks given book ob and
+// the index at spot (0 if unknown), or "" to hold.
+func (r ExitRules) Check(ms *MarketState, ob *kalshi.OrderbookState, spot float64) string {
+	if r.OnFlip {
+		if mp := sideMicroprice(ob, ms.Side); mp > 0 && mp < 50 {
+			return ExitBookFlip
+		}
+	}
+	if r.StrikeCross > 0 && ms.StrikeFetched {
+		if d, ok := adverseMove(ms.StrikeSpec, ms.Side, spot); ok && d >= r.StrikeCross {
+			return ExitStrikeCross
+		}
+	}
+	if r.MaxDrawdown > 0 && ms.EntryPrice > 0 {
+		if bid := sideBid(ob, ms.Side); bid > 0 && float64(ms.EntryPrice-bid) >= r.MaxDrawdown*float64(ms.EntryPrice) {
+			return ExitDrawdown
+		}
+	}
+	return ""
+}
+
+// sideBid returns the best bid for side's contracts, 0 if there is none.
+func sideBid(ob *kalshi.OrderbookState, side string) int {
+	if side == "yes" {
+		return ob.BestYesBid()
+	}
+	return 100 - ob.BestYesAsk()
+}
+
+// sideMicroprice returns ob's microprice in side's terms, 0 if the book is
+// one-sided.
+func sideMicroprice(ob *kalshi.OrderbookState, side string) float64 {
+	mp := ob.Microprice()
+	if mp == 0 || side == "yes" {
+		return mp
+	}
+	return 100 - mp
+}
+
+// adverseMove returns how many dollars spot is on the losing side of the
+// strike for side; negative when side is winning. ok is false for range
+// and custom-strike markets and unknown prices.
+func adverseMove(spec kalshi.StrikeSpec, side string, spot float64) (float64, bool) {
+	if spot <= 0 || spec.Strike() <= 0 || spec.Type == kalshi.StrikeBetween || spec.Type == kalshi.StrikeCustom {
+		return 0, false
+	}
+	d := spec.Strike() - spot // YES loses below the strike...
+	if spec.Type == kalshi.StrikeLess || spec.Type == kalshi.StrikeLessOrEqual {
+		d = -d // ...or above it
+	}
+	if side == "no" {
+		d = -d
+	}
+	return d, true
+}
+
+// managePosition watches an open position until shortly before close,
+// selling what is still held at the bid when an exit rule fires.
+func (e *Engine) managePosition(ctx context.Context, ms *MarketState) {
+	if ms.ExitPending {
+		e.checkExitStatus(ctx, ms)
+		return
+	}
+
+	held := ms.Contracts - ms.ExitedContracts
+	if !e.exitRules.Enabled() || held <= 0 || e.halted {
+		return
+	}
+	if time.Until(ms.CloseTime) < exitCutoff || time.Since(ms.ExitPlacedAt) < 2*time.Second {
+		return
+	}
+
+	ob := e.market.GetOrderbook(ms.Ticker)
+	if ob == nil {
+		return
+	}
+	if _, stale := e.bookStale(ob); stale {
+		return
+	}
+	var spot float64
+	if series := e.seriesFor(ms.Ticker); series != nil {
+		spot = series.volFilter.Price()
+	}
+
+	reason := e.exitRules.Check(ms, ob, spot)
+	if reason == "" {
+		return
+	}
+	bid := sideBid(ob, ms.Side)
+	if bid == 0 {
+		slog.Debug("exit deferred - no bid", "ticker", ms.Ticker, "side", ms.Side, "reason", reason)
+		return
+	}
+
+	slog.Info("exit triggered",
+		"ticker", ms.Ticker,
+		"reason", reason,
+		"side", ms.Side,
+		"held", held,
+		"entry", ms.EntryPrice,
+		"bid", bid,
+		"microprice", fmt.Sprintf("%.1f", sideMicroprice(ob, ms.Side)),
+		"spot", spot,
+		"strike", ms.Strike,
+		"secsUntilClose", int(time.Until(ms.CloseTime).Seconds()),
+	)
+	e.placeExit(ctx, ms, held, bid, reason)
+}
+
+// placeExit sells count contracts of the position at price. The order is
+// immediate-or-cancel: whatever doesn't fill at once is left for the next
+// check against a fresh book.
+func (e *Engine) placeExit(ctx context.Context, ms *MarketState, count, price int, reason string) {
+	ms.ExitReason = reason
+	ms.ExitPlacedAt = time.Now()
+
+	if e.cfg.DryRun {
+		// Dry run: simulate an immediate fill at the bid
+		e.recordExit(ms, "dry-run", count, count*price, TakerFee(count, price), price)
+		return
+	}
+
+	req := kalshi.OrderRequest{
+		Ticker:        ms.Ticker,
+		ClientOrderID: ExitClientOrderID(ms.Ticker, ms.Side, price, ms.ExitAttempts),
+		Action:        "sell",
+		Side:          ms.Side,
+		Type:          "limit",
+		Count:         count,
+		TimeInForce:   "immediate_or_cancel",
+	}
+	if ms.Side == "yes" {
+		req.YesPrice = price
+	} else {
+		req.NoPrice = price
+	}
+	ms.ExitAttempts++
+	ms.ExitClientOrderID = req.ClientOrderID
+
+	order, err := e.orders.CreateOrder(ctx, req)
+	if err != nil {
+		if kalshi.IsRejected(err) {
+			slog.Error("exit order placement failed", "ticker", ms.Ticker, "err", err)
+			return
+		}
+		slog.Warn("exit order placement ambiguous — resolving by client order id",
+			"ticker", ms.Ticker,
+			"clientOrderID", req.ClientOrderID,
+			"err", err,
+		)
+		ms.ExitPending = true
+		ms.ExitOrderID = ""
+		return
+	}
+
+	ms.ExitPending = true
+	ms.ExitOrderID = order.OrderID
+	slog.Info("exit order placed",
+		"ticker", ms.Ticker,
+		"orderID", order.OrderID,
+		"side", ms.Side,
+		"price", price,
+		"contracts", count,
+		"reason", reason,
+	)
+}
+
+// checkExitStatus follows a working exit order to its end and records what
+// it sold. An exit whose placement was ambiguous is looked up by
+// client_order_id first; if the exchange never got it, the rules are
+// simply rechecked on the next tick.
+func (e *Engine) checkExitStatus(ctx context.Context, ms *MarketState) {
+	if ms.ExitOrderID == "" {
+		if time.Since(ms.LastExitLookup) < 2*time.Second {
+			return
+		}
+		ms.LastExitLookup = time.Now()
+
+		order, err := e.orders.GetOrderByClientID(ctx, ms.Ticker, ms.ExitClientOrderID)
+		switch {
+		case errors.Is(err, kalshi.ErrOrderNotFound):
+			slog.Info("exit order never reached the exchange", "ticker", ms.Ticker, "clientOrderID", ms.ExitClientOrderID)
+			ms.ExitPending = false
+			return
+		case err != nil:
+			slog.Warn("exit order lookup failed", "ticker", ms.Ticker, "err", err)
+			return
+		}
+		ms.ExitOrderID = order.OrderID
+	}
+
+	order, pushed := e.orders.GetOrderUpdate(ms.ExitOrderID)
+	if !pushed || !order.IsFinal() {
+		freshFill := e.orders.LastFillTime(ms.ExitOrderID).After(ms.LastExitLookup)
+		if !freshFill && time.Since(ms.LastExitLookup) < time.Second {
+			return
+		}
+		ms.LastExitLookup = time.Now()
+
+		var err error
+		order, err = e.orders.GetOrder(ctx, ms.ExitOrderID)
+		if err != nil {
+			slog.Warn("exit order status check failed", "ticker", ms.Ticker, "orderID", ms.ExitOrderID, "err", err)
+			return
+		}
+	}
+
+	if !order.IsFinal() {
+		if time.Since(ms.ExitPlacedAt) < exitOrderTimeout {
+			return
+		}
+		canceled, err := e.orders.CancelOrder(ctx, ms.ExitOrderID)
+		if err != nil {
+			slog.Warn("exit order cancel failed", "ticker", ms.Ticker, "err", err)
+			return
+		}
+		order = canceled
+	}
+
+	ms.ExitPending = false
+	e.orders.ForgetOrder(ms.ExitOrderID)
+
+	if order.FilledCount == 0 {
+		slog.Info("exit order closed unfilled", "ticker", ms.Ticker, "status", order.Status)
+		return
+	}
+
+	proceeds := order.TakerFillCost + order.MakerFillCost
+	if proceeds == 0 {
+		proceeds = order.FilledCount * order.LimitPrice()
+	}
+	fee := order.FeesPaid()
+	if fee == 0 {
+		fee = TakerFee(order.FilledCount, order.AvgFillPrice())
+	}
+	e.recordExit(ms, ms.ExitOrderID, order.FilledCount, proceeds, fee, order.LimitPrice())
+}
+
+// recordExit books count contracts sold for proceeds cents (before fee)
+// and journals the sell.
+func (e *Engine) recordExit(ms *MarketState, orderID string, count, proceeds, fee, limitPrice int) {
+	ms.ExitedContracts += count
+	ms.ExitProceeds += proceeds
+	ms.ExitFeeCents += fee
+
+	avg := float64(proceeds) / float64(count)
+	if err := e.journal.Log(journal.NewTrade(
+		ms.Ticker, ms.Side, "sell",
+		proceeds/count, count, fee,
+		orderID, count, e.cfg.DryRun, limitPrice,
+	).WithExecution(float64(limitPrice), avg).WithExitReason(ms.ExitReason)); err != nil {
+		slog.Error("failed to journal exit",
+			"ticker", ms.Ticker,
+			"err", err,
+		)
+	}
+
+	slog.Info("position exited",
+		"ticker", ms.Ticker,
+		"side", ms.Side,
+		"reason", ms.ExitReason,
+		"sold", count,
+		"avgPrice", fmt.Sprintf("%.2f", avg),
+		"fee", fee,
+		"entry", ms.EntryPrice,
+		"stillHeld", ms.Contracts-ms.ExitedContracts,
+		"dryRun", e.cfg.DryRun,
+	)
+}
@@ -0,0 +1,77 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+func TestExitRulesCheck(t *testing.T) {
+	above := kalshi.StrikeSpec{Type: kalshi.StrikeGreaterOrEqual, Floor: 68000}
+	below := kalshi.StrikeSpec{Type: kalshi.StrikeLess, Cap: 68000}
+	all := ExitRules{OnFlip: true, StrikeCross: 50, MaxDrawdown: 0.25}
+
+	tests := []struct {
+		name   string
+		rules  ExitRules
+		side   string
+		spec   kalshi.StrikeSpec
+		yesBid int
+		noBid  int
+		spot   float64
+		want   string
+	}{
+		{"holding yes, all well", all, "yes", above, 84, 14, 68100, ""},
+		{"holding yes, book flipped", all, "yes", above, 35, 60, 68100, ExitBookFlip},
+		{"holding no, book flipped", all, "no", above, 84, 14, 67900, ExitBookFlip},
+		{"flip rule off", ExitRules{}, "yes", above, 35, 60, 68100, ""},
+		{"yes, index below strike", all, "yes", above, 80, 18, 67940, ExitStrikeCross},
+		{"yes, index just below strike", all, "yes", above, 80, 18, 67960, ""},
+		{"no, index above strike", all, "no", above, 18, 80, 68060, ExitStrikeCross},
+		{"yes on a less-than market, index above cap", all, "yes", below, 80, 18, 68060, ExitStrikeCross},
+		{"unknown spot", all, "yes", above, 80, 18, 0, ""},
+		{"drawdown past limit", all, "yes", above, 63, 30, 68100, ExitDrawdown},
+		{"drawdown within limit", all, "yes", above, 64, 30, 68100, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob, err := kalshi.NewOrderbook("T",
+				[]kalshi.PriceLevel{{Price: tt.yesBid, Quantity: 100}},
+				[]kalshi.PriceLevel{{Price: tt.noBid, Quantity: 100}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ms := &MarketState{Side: tt.side, EntryPrice: 85, StrikeSpec: tt.spec, StrikeFetched: true}
+			if got := tt.rules.Check(ms, ob, tt.spot); got != tt.want {
+				t.Errorf("Check = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositionPnL(t *testing.T) {
+	fee := TakerFee(20, 85)
+	for _, won := range []bool{true, false} {
+		if got, want := PositionPnL(won, 85, 20, fee, 0, 0, 0), ComputePnL(won, 85, 20, fee); got != want {
+			t.Errorf("won=%v without exits: PositionPnL = %d, want ComputePnL %d", won, got, want)
+		}
+	}
+
+	// 5 of 20 sold at 40c; the other 15 settle
+	exitFee := TakerFee(5, 40)
+	if got, want := PositionPnL(true, 85, 20, fee, 5, 200, exitFee), 200-exitFee+1500-1700-fee; got != want {
+		t.Errorf("partial exit, won: PositionPnL = %d, want %d", got, want)
+	}
+	if got, want := PositionPnL(false, 85, 20, fee, 20, 800, exitFee), 800-exitFee-1700-fee; got != want {
+		t.Errorf("full exit: PositionPnL = %d, want %d", got, want)
+	}
+}
+
+func TestExitCutoffLeavesRoomToRetry(t *testing.T) {
+	// An exit placed at the cutoff must be able to time out and be retried
+	// before close
+	if exitCutoff <= exitOrderTimeout+2*time.Second {
+		t.Errorf("exitCutoff %v leaves no room after exitOrderTimeout %v", exitCutoff, exitOrderTimeout)
+	}
+}
@@ -0,0 +1,91 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// settlementAverage is how long before close the settlement index is
+// averaged over (the simple average of BRTI over the final 60 seconds).
+const settlementAverage = 60 * time.Second
+
+// FairValue returns the model probability that a market settles YES.
+//
+// The index follows geometric Brownian motion from spot with log-volatility
+// vol per square root of second (see VolFilter.RealizedVol). The market
+// settles on the average over settlementAverage before close, which is
+// treated as lognormal with the variance of that average:
+//
+//	P(avg >= K) = Φ((ln(spot/K) - s²/2) / s),  s² = vol² · averagingTime
+//
+// ok is false when spot, vol or the strike is unknown, and for custom
+// strikes, whose comparison is only in the rules text.
+func FairValue(spec kalshi.StrikeSpec, spot, vol float64, untilClose time.Duration) (p float64, ok bool) {
+	if spot <= 0 || vol <= 0 || spec.Strike() <= 0 || spec.Type == kalshi.StrikeCustom {
+		return 0, false
+	}
+	s := vol * math.Sqrt(averagingTime(untilClose))
+
+	above := func(k float64) float64 {
+		if s == 0 {
+			if spot >= k {
+				return 1
+			}
+			return 0
+		}
+		return normCDF((math.Log(spot/k) - s*s/2) / s)
+	}
+
+	switch spec.Type {
+	case kalshi.StrikeLess, kalshi.StrikeLessOrEqual:
+		return 1 - above(spec.Cap), true
+	case kalshi.StrikeBetween:
+		if spec.Cap <= 0 {
+			return 0, false
+		}
+		return math.Max(0, above(spec.Floor)-above(spec.Cap)), true
+	default:
+		return above(spec.Floor), true
+	}
+}
+
+// averagingTime returns the variance, in units of per-second variance, of
+// the average of a Brownian path over the settlement window, seen
+// untilClose before close. Before the window opens the path drifts freely
+// until it starts and then contributes a third of the window; inside it,
+// only the unobserved remainder is uncertain.
+func averagingTime(untilClose time.Duration) float64 {
+	t, w := untilClose.Seconds(), settlementAverage.Seconds()
+	switch {
+	case t <= 0:
+		return 0
+	case t >= w:
+		return t - w + w/3
+	default:
+		return t * t * t / (3 * w * w)
+	}
+}
+
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// fairValue returns the model probability that ms settles YES, from its
+// series' latest index price and realized volatility.
+func (e *Engine) fairValue(series *SeriesState, ms *MarketState, untilClose time.Duration) (float64, bool) {
+	if !ms.StrikeFetched {
+		return 0, false
+	}
+	return FairValue(ms.StrikeSpec, series.volFilter.Price(), series.volFilter.RealizedVol(), untilClose)
+}
+
+// sideEdge returns how far, in cents, the model values side above price.
+func sideEdge(pYes float64, side string, price int) float64 {
+	p := pYes
+	if side == "no" {
+		p = 1 - pYes
+	}
+	return 100*p - float64(price)
+}
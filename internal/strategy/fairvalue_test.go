@@ -0,0 +1,88 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+func TestFairValue(t *testing.T) {
+	above := kalshi.StrikeSpec{Type: kalshi.StrikeGreaterOrEqual, Floor: 68000}
+	below := kalshi.StrikeSpec{Type: kalshi.StrikeLess, Cap: 68000}
+	band := kalshi.StrikeSpec{Type: kalshi.StrikeBetween, Floor: 67900, Cap: 68100}
+	const vol = 0.0001 // per √s, about 2.9% a day
+
+	tests := []struct {
+		name       string
+		spec       kalshi.StrikeSpec
+		spot       float64
+		untilClose time.Duration
+		lo, hi     float64
+	}{
+		{"at the money is a coin flip", above, 68000, 4 * time.Minute, 0.49, 0.5},
+		{"$300 above with 4 min left", above, 68300, 4 * time.Minute, 0.97, 1},
+		{"$300 below with 4 min left", above, 67700, 4 * time.Minute, 0, 0.03},
+		{"$50 above with 4 min left", above, 68050, 4 * time.Minute, 0.65, 0.75},
+		{"$50 above inside the averaging window", above, 68050, 10 * time.Second, 0.999, 1},
+		{"less is the complement", below, 68050, 4 * time.Minute, 0.25, 0.35},
+		{"band around spot", band, 68000, 4 * time.Minute, 0.65, 0.75},
+		{"after close the side is known", above, 68001, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := FairValue(tt.spec, tt.spot, vol, tt.untilClose)
+			if !ok || p < tt.lo || p > tt.hi {
+				t.Errorf("FairValue = %.4f, %v; want in [%.2f, %.2f]", p, ok, tt.lo, tt.hi)
+			}
+		})
+	}
+
+	// YES probabilities of complementary markets sum to one
+	pAbove, _ := FairValue(above, 68050, vol, 4*time.Minute)
+	pBelow, _ := FairValue(below, 68050, vol, 4*time.Minute)
+	if math.Abs(pAbove+pBelow-1) > 1e-12 {
+		t.Errorf("above %.4f + below %.4f != 1", pAbove, pBelow)
+	}
+
+	for _, missing := range []struct {
+		spec      kalshi.StrikeSpec
+		spot, vol float64
+	}{
+		{above, 0, vol},
+		{above, 68000, 0},
+		{kalshi.StrikeSpec{Type: kalshi.StrikeGreater}, 68000, vol},
+	} {
+		if _, ok := FairValue(missing.spec, missing.spot, missing.vol, time.Minute); ok {
+			t.Errorf("FairValue(%+v, %v, %v) ok with missing input", missing.spec, missing.spot, missing.vol)
+		}
+	}
+}
+
+func TestAveragingTime(t *testing.T) {
+	tests := []struct {
+		untilClose time.Duration
+		want       float64
+	}{
+		{4 * time.Minute, 180 + 20},
+		{time.Minute, 20},       // window just opening: a third of it
+		{30 * time.Second, 2.5}, // (1/2)² of the remaining 30s / 3
+		{0, 0},
+		{-time.Second, 0},
+	}
+	for _, tt := range tests {
+		if got := averagingTime(tt.untilClose); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("averagingTime(%v) = %g, want %g", tt.untilClose, got, tt.want)
+		}
+	}
+}
+
+func TestSideEdge(t *testing.T) {
+	if got := sideEdge(0.9, "yes", 85); math.Abs(got-5) > 1e-9 {
+		t.Errorf("YES edge = %g, want 5", got)
+	}
+	if got := sideEdge(0.9, "no", 8); math.Abs(got-2) > 1e-9 {
+		t.Errorf("NO edge = %g, want 2", got)
+	}
+}
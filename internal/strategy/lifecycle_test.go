@@ -0,0 +1,1022 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+	"github.com/sdibella/kalshi-btc15m/internal/strategy/strategytest"
+)
+
+const lifecycleTicker = "KXBTC15M-26OCT161200-00"
+
+// lifecycle is one engine tracking one market, wired to fakes.
+type lifecycle struct {
+	e  *Engine
+	ms *MarketState
+
+	market    *strategytest.MarketData
+	orders    *strategytest.Orders
+	portfolio *strategytest.Portfolio
+	journal   *strategytest.Journal
+}
+
+// newLifecycle tracks a subscribed market with a known strike that is
+// inside the entry window, with a $1000 balance. configure, if set,
+// adjusts the engine's config.
+func newLifecycle(t *testing.T, configure func(*config.Config)) *lifecycle {
+	t.Helper()
+	l := &lifecycle{
+		market:    strategytest.NewMarketData(),
+		orders:    strategytest.NewOrders(),
+		portfolio: &strategytest.Portfolio{Balance: 100_000},
+		journal:   &strategytest.Journal{},
+	}
+	cfg := &config.Config{
+		Series:       []string{"KXBTC15M"},
+		VolDataDir:   t.TempDir(),
+		VolMaxStdDev: 200,
+		MaxBookAge:   time.Minute,
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	var err error
+	l.e, err = NewEngine(Deps{
+		Market:    l.market,
+		Orders:    l.orders,
+		Portfolio: l.portfolio,
+		Journal:   l.journal,
+	}, cfg)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	l.e.balance = l.portfolio.Balance
+
+	m := &kalshi.Market{
+		Ticker:      lifecycleTicker,
+		Status:      "open",
+		StrikeType:  "greater_or_equal",
+		FloorStrike: 68000,
+	}
+	l.market.Markets[m.Ticker] = m
+	l.ms = &MarketState{Ticker: m.Ticker, Series: "KXBTC15M", Subscribed: true}
+	if err := applyStrike(l.ms, m); err != nil {
+		t.Fatalf("applyStrike: %v", err)
+	}
+	l.market.SubscribeAsync([]string{m.Ticker})
+	l.e.markets[m.Ticker] = l.ms
+	l.closeIn((entryWindowStart - 5) * time.Second)
+	return l
+}
+
+// closeIn moves the market's close time to d from now.
+func (l *lifecycle) closeIn(d time.Duration) {
+	closeTime := time.Now().Add(d).Truncate(time.Second)
+	l.ms.CloseTime = closeTime
+	l.market.Markets[l.ms.Ticker].CloseTime = closeTime.UTC().Format(time.RFC3339)
+}
+
+// book sets YES and NO bids of 100 contracts each.
+func (l *lifecycle) book(t *testing.T, yesBid, noBid int) {
+	t.Helper()
+	err := l.market.SetBook(l.ms.Ticker,
+		[]kalshi.PriceLevel{{Price: yesBid, Quantity: 100}},
+		[]kalshi.PriceLevel{{Price: noBid, Quantity: 100}})
+	if err != nil {
+		t.Fatalf("SetBook: %v", err)
+	}
+}
+
+// spot feeds the series' index price history, 10s apart and ending now.
+func (l *lifecycle) spot(prices ...float64) {
+	vf := l.e.series[0].volFilter
+	vf.mu.Lock()
+	defer vf.mu.Unlock()
+	now := time.Now()
+	for i, p := range prices {
+		ts := now.Add(time.Duration(i-len(prices)+1) * 10 * time.Second)
+		vf.samples = append(vf.samples, priceSample{Price: p, Time: ts})
+	}
+}
+
+// holding puts the market in the state of a filled entry.
+func (l *lifecycle) holding(side string, price, contracts int) {
+	l.ms.Evaluated = true
+	l.ms.Traded = true
+	l.ms.Side = side
+	l.ms.EntryPrice = price
+	l.ms.Contracts = contracts
+	l.ms.FeeCents = TakerFee(contracts, price)
+}
+
+// settle determines the market and, if record is set, publishes the
+// exchange's settlement record for the held position.
+func (l *lifecycle) settle(result string, record bool) {
+	l.market.Markets[l.ms.Ticker].Result = result
+	l.market.Results[l.ms.Ticker] = result
+	if !record {
+		return
+	}
+	st := kalshi.Settlement{
+		Ticker:       l.ms.Ticker,
+		MarketResult: result,
+		FeeCost:      fmt.Sprintf("%.4f", float64(l.ms.FeeCents)/100),
+	}
+	if l.ms.Side == "yes" {
+		st.YesCount, st.YesTotalCost = l.ms.Contracts, l.ms.Contracts*l.ms.EntryPrice
+	} else {
+		st.NoCount, st.NoTotalCost = l.ms.Contracts, l.ms.Contracts*l.ms.EntryPrice
+	}
+	if result == l.ms.Side {
+		st.Revenue = 100 * l.ms.Contracts
+	}
+	l.portfolio.Settlements = append(l.portfolio.Settlements, st)
+}
+
+// placed returns the only order sent, failing if there isn't exactly one.
+func (l *lifecycle) placed(t *testing.T) kalshi.OrderRequest {
+	t.Helper()
+	if len(l.orders.Placed) != 1 {
+		t.Fatalf("placed %d orders, want 1: %+v", len(l.orders.Placed), l.orders.Placed)
+	}
+	return l.orders.Placed[0]
+}
+
+// orderID returns the ID the exchange gave the engine's order.
+func (l *lifecycle) orderID(t *testing.T) string {
+	t.Helper()
+	o, err := l.orders.GetOrderByClientID(context.Background(), l.ms.Ticker, l.placed(t).ClientOrderID)
+	if err != nil {
+		t.Fatalf("order not on the exchange: %v", err)
+	}
+	return o.OrderID
+}
+
+func (l *lifecycle) tracked() bool {
+	_, ok := l.e.markets[l.ms.Ticker]
+	return ok
+}
+
+func TestMarketLifecycle(t *testing.T) {
+	size := KellySize(85, 100_000)
+	rejected := &kalshi.APIError{StatusCode: http.StatusBadRequest, Code: "insufficient_balance"}
+	lost := errors.New("read: connection reset by peer")
+	dryRun := func(c *config.Config) { c.DryRun = true }
+	spec55 := func(c *config.Config) { c.SeriesStrategies = map[string]string{"KXBTC15M": "55c-4min"} }
+	fairValue := func(c *config.Config) { c.FairValueFilter, c.FairValueMinEdge = true, 2 }
+	posterior := func(c *config.Config) {
+		c.KellyPosterior, c.KellyQuantile, c.PosteriorBucketCents, c.PosteriorMinObs = true, 0.05, 5, 30
+	}
+	depth := func(c *config.Config) { c.DepthSizing, c.MaxSlippageCents = true, 2 }
+	// thinTop offers 50 YES at 85 and 100 more at 86, with the YES bid at 82
+	thinTop := func(t *testing.T, l *lifecycle) {
+		err := l.market.SetBook(lifecycleTicker,
+			[]kalshi.PriceLevel{{Price: 82, Quantity: 100}},
+			[]kalshi.PriceLevel{{Price: 14, Quantity: 100}, {Price: 15, Quantity: 50}})
+		if err != nil {
+			t.Fatalf("SetBook: %v", err)
+		}
+	}
+	exitOnFlip := func(c *config.Config) { c.ExitOnFlip = true }
+	record := func(l *lifecycle, key WinRateKey, wins, losses int) {
+		for i := 0; i < wins+losses; i++ {
+			l.e.winRates.Observe(key, i < wins)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		config func(*config.Config)
+		setup  func(t *testing.T, l *lifecycle)
+		run    func(ctx context.Context, t *testing.T, l *lifecycle)
+		check  func(t *testing.T, l *lifecycle)
+	}{
+		{
+			name:  "signal places a limit buy at the ask",
+			setup: func(t *testing.T, l *lifecycle) { l.book(t, 82, 15) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				req := l.placed(t)
+				if req.Action != "buy" || req.Side != "yes" || req.YesPrice != 85 || req.Count != size ||
+					req.TimeInForce != "good_till_canceled" || req.ClientOrderID != ClientOrderID(lifecycleTicker, "buy", "yes", 85) {
+					t.Errorf("order = %+v, want buy %d YES @ 85", req, size)
+				}
+				if !l.ms.Evaluated || !l.ms.OrderPending || l.ms.OrderID == "" || l.ms.Traded {
+					t.Errorf("state = %+v, want pending order", l.ms)
+				}
+			},
+		},
+		{
+			name:  "NO signal buys NO at 100 minus the YES bid",
+			setup: func(t *testing.T, l *lifecycle) { l.book(t, 12, 80) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if req := l.placed(t); req.Side != "no" || req.NoPrice != 88 || req.YesPrice != 0 {
+					t.Errorf("order = %+v, want NO @ 88", req)
+				}
+			},
+		},
+		{
+			name:  "no signal below threshold keeps rechecking",
+			setup: func(t *testing.T, l *lifecycle) { l.book(t, 60, 35) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 || l.ms.Evaluated {
+					t.Errorf("placed %v, evaluated %v; want neither", l.orders.Placed, l.ms.Evaluated)
+				}
+			},
+		},
+		{
+			name: "no entry before the window opens",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.closeIn(5 * time.Minute)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 {
+					t.Errorf("placed %v outside the entry window", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name: "no entry while the exchange is halted",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.e.halted = true
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 {
+					t.Errorf("placed %v while halted", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name: "rejected subscribe is retried",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.market.UnsubscribeAsync([]string{lifecycleTicker})
+				l.ms.Subscribed = false
+				l.market.Reject[lifecycleTicker] = errors.New("unknown market")
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.subscribe(l.ms)
+				if l.ms.Subscribed {
+					t.Fatal("subscribed after a rejection")
+				}
+				delete(l.market.Reject, lifecycleTicker)
+				l.e.processMarket(ctx, l.ms)
+				if l.ms.Subscribed {
+					t.Fatal("resubscribed before the retry interval")
+				}
+				l.ms.LastSubscribe = time.Now().Add(-subscribeRetryInterval)
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if !l.ms.Subscribed || !l.market.Subscribed[lifecycleTicker] {
+					t.Errorf("Subscribed = %v, want the retry acknowledged", l.ms.Subscribed)
+				}
+			},
+		},
+		{
+			name: "stale book defers evaluation",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.market.Books[lifecycleTicker].LastUpdate = time.Now().Add(-2 * time.Minute)
+				l.market.Heard = time.Now().Add(-2 * time.Minute)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 || l.ms.Evaluated {
+					t.Errorf("placed %v on a stale book", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name: "quiet book on a live connection is evaluated",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.market.Books[lifecycleTicker].LastUpdate = time.Now().Add(-2 * time.Minute)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				l.placed(t)
+			},
+		},
+		{
+			name: "strike is fetched before evaluating",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.ms.StrikeFetched = false
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if l.market.MarketGets != 1 || !l.ms.StrikeFetched || l.ms.Strike != 68000 {
+					t.Errorf("strike %v fetched=%v after %d GetMarket calls", l.ms.Strike, l.ms.StrikeFetched, l.market.MarketGets)
+				}
+				l.placed(t)
+			},
+		},
+		{
+			name:  "kelly declines a 95c entry",
+			setup: func(t *testing.T, l *lifecycle) { l.book(t, 92, 5) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 || !l.ms.Evaluated || l.ms.OrderPending {
+					t.Errorf("placed %v, state %+v; want evaluated without an order", l.orders.Placed, l.ms)
+				}
+			},
+		},
+		{
+			name:   "dry run journals a simulated fill",
+			config: dryRun,
+			setup:  func(t *testing.T, l *lifecycle) { l.book(t, 82, 15) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				trades := l.journal.Trades()
+				if len(l.orders.Placed) != 0 || len(trades) != 1 || !trades[0].DryRun || trades[0].Filled != size {
+					t.Fatalf("placed %v, journaled %+v; want one dry-run trade of %d", l.orders.Placed, trades, size)
+				}
+				if !l.ms.Traded || l.ms.EntryPrice != 85 || l.ms.FeeCents != TakerFee(size, 85) {
+					t.Errorf("state = %+v", l.ms)
+				}
+			},
+		},
+		{
+			name:   "55c strategy joins the bid with one contract",
+			config: spec55,
+			setup:  func(t *testing.T, l *lifecycle) { l.book(t, 60, 35) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if req := l.placed(t); req.Side != "yes" || req.YesPrice != 60 || req.Count != 1 {
+					t.Errorf("order = %+v, want 1 YES @ 60 (the bid)", req)
+				}
+			},
+		},
+		{
+			name:   "55c strategy evaluates once",
+			config: spec55,
+			setup:  func(t *testing.T, l *lifecycle) { l.book(t, 50, 48) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.book(t, 60, 35)
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 || !l.ms.Evaluated {
+					t.Errorf("placed %v after a first tick without a signal", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name:   "fair value filter skips an ask above the model",
+			config: fairValue,
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.spot(68000, 68010, 67990, 68000) // at the strike: worth ~50c
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 || l.ms.Evaluated {
+					t.Errorf("placed %v, evaluated %v; want skipped and rechecked", l.orders.Placed, l.ms.Evaluated)
+				}
+			},
+		},
+		{
+			name:   "fair value filter passes an ask below the model",
+			config: fairValue,
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.spot(68400, 68410, 68390, 68400) // $400 over the strike
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if req := l.placed(t); req.YesPrice != 85 {
+					t.Errorf("order = %+v, want YES @ 85", req)
+				}
+			},
+		},
+		{
+			name:   "fair value filter skips without index data",
+			config: fairValue,
+			setup:  func(t *testing.T, l *lifecycle) { l.book(t, 82, 15) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 {
+					t.Errorf("placed %v without a fair value", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name: "rejected order is dropped",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.orders.OnCreate = func(kalshi.OrderRequest) (*kalshi.Order, error) { return nil, rejected }
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				l.placed(t)
+				if l.ms.OrderPending || l.ms.Traded {
+					t.Errorf("state = %+v, want nothing pending", l.ms)
+				}
+			},
+		},
+		{
+			name: "lost response is resolved by client order id",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.orders.OnCreate = func(req kalshi.OrderRequest) (*kalshi.Order, error) {
+					return l.orders.Rest(req), lost
+				}
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				if !l.ms.OrderUnconfirmed {
+					t.Fatalf("state = %+v, want unconfirmed after a lost response", l.ms)
+				}
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if l.ms.OrderUnconfirmed || !l.ms.OrderPending || l.ms.OrderID != l.orderID(t) {
+					t.Errorf("state = %+v, want the exchange's order adopted", l.ms)
+				}
+			},
+		},
+		{
+			name: "order that never landed is re-sent",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				l.orders.OnCreate = func(req kalshi.OrderRequest) (*kalshi.Order, error) {
+					l.orders.OnCreate = nil
+					return nil, lost
+				}
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				placed := l.orders.Placed
+				if len(placed) != 2 || placed[1] != placed[0] {
+					t.Fatalf("placed %+v, want the identical request twice", placed)
+				}
+				if l.ms.OrderUnconfirmed || l.ms.OrderResends != 1 || l.ms.OrderID == "" {
+					t.Errorf("state = %+v, want confirmed after one resend", l.ms)
+				}
+			},
+		},
+		{
+			name:  "pushed execution journals the trade",
+			setup: func(t *testing.T, l *lifecycle) { l.book(t, 82, 15) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.orders.Execute(l.ms.OrderID, size, 85)
+				l.e.handleEvent(ctx, kalshi.StreamEvent{Kind: kalshi.EventOrder, Ticker: lifecycleTicker})
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				trades := l.journal.Trades()
+				if len(trades) != 1 || trades[0].Filled != size || trades[0].Price != 85 || trades[0].DryRun {
+					t.Fatalf("journaled %+v, want %d filled @ 85", trades, size)
+				}
+				if !l.ms.Traded || l.ms.OrderPending || l.ms.Contracts != size || l.ms.FeeCents != TakerFee(size, 85) {
+					t.Errorf("state = %+v", l.ms)
+				}
+				if _, ok := l.orders.GetOrderUpdate(l.ms.OrderID); ok {
+					t.Error("order push not forgotten")
+				}
+			},
+		},
+		{
+			name:   "depth sizing sweeps to the worst level worth taking",
+			config: depth,
+			setup:  thinTop,
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				// the top level was taken before we got there
+				l.orders.Execute(l.ms.OrderID, l.placed(t).Count, 86)
+				l.e.handleEvent(ctx, kalshi.StreamEvent{Kind: kalshi.EventOrder, Ticker: lifecycleTicker})
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				want := depthKelly(fixedWinRate, 86, 100_000)
+				if req := l.placed(t); req.YesPrice != 86 || req.Count != want {
+					t.Fatalf("order = %+v, want %d YES @ 86", req, want)
+				}
+				expected := float64(50*85+(want-50)*86) / float64(want)
+				trades := l.journal.Trades()
+				if len(trades) != 1 || trades[0].ExpectedAvgPrice != expected || trades[0].RealizedAvgPrice != 86 {
+					t.Errorf("journaled %+v, want expected %.2f, realized 86", trades, expected)
+				}
+			},
+		},
+		{
+			name: "depth sizing stops at the slippage cap",
+			config: func(c *config.Config) {
+				depth(c)
+				c.MaxSlippageCents = 0
+			},
+			setup: thinTop,
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if req := l.placed(t); req.YesPrice != 85 || req.Count != 50 {
+					t.Errorf("order = %+v, want the 50 at 85", req)
+				}
+				if l.ms.ExpectedPrice != 85 {
+					t.Errorf("ExpectedPrice = %v, want 85", l.ms.ExpectedPrice)
+				}
+			},
+		},
+		{
+			name:  "partial fill is canceled after 30s",
+			setup: func(t *testing.T, l *lifecycle) { l.book(t, 82, 15) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.orders.Execute(l.ms.OrderID, 10, 85)
+				l.ms.OrderPlacedAt = time.Now().Add(-31 * time.Second)
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Cancels) != 1 || !l.ms.Traded || l.ms.Contracts != 10 || l.ms.EntryPrice != 85 {
+					t.Errorf("cancels %v, state %+v; want 10 contracts kept", l.orders.Cancels, l.ms)
+				}
+				if trades := l.journal.Trades(); len(trades) != 1 || trades[0].Filled != 10 {
+					t.Errorf("journaled %+v", trades)
+				}
+			},
+		},
+		{
+			name:  "unfilled order is canceled without a trade",
+			setup: func(t *testing.T, l *lifecycle) { l.book(t, 82, 15) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.ms.OrderPlacedAt = time.Now().Add(-31 * time.Second)
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Cancels) != 1 || l.ms.Traded || l.ms.OrderPending || len(l.journal.Events) != 0 {
+					t.Errorf("cancels %v, state %+v, journal %v", l.orders.Cancels, l.ms, l.journal.Events)
+				}
+			},
+		},
+		{
+			name: "settlement waits for the market result",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.closeIn(-time.Minute)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if l.ms.Settled || len(l.journal.Events) != 0 || !l.tracked() {
+					t.Errorf("settled before a result: %+v", l.journal.Events)
+				}
+			},
+		},
+		{
+			name: "settlement waits for the exchange record",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.closeIn(-time.Minute)
+				l.settle("yes", false)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if l.ms.Settled || len(l.journal.Events) != 0 || l.ms.ResultSeenAt.IsZero() {
+					t.Errorf("state = %+v, journal %v; want waiting for the record", l.ms, l.journal.Events)
+				}
+			},
+		},
+		{
+			name: "won settlement journals exchange P&L",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.closeIn(-time.Minute)
+				l.settle("yes", true)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				want := l.portfolio.Settlements[0].PnL()
+				sts := l.journal.Settlements()
+				if len(sts) != 1 || sts[0].PnLSource != "exchange" || sts[0].PnLCents != want || !sts[0].Won || sts[0].PnLMismatch {
+					t.Fatalf("journaled %+v, want exchange P&L %d", sts, want)
+				}
+				if !l.ms.Settled || l.tracked() || l.market.Subscribed[lifecycleTicker] {
+					t.Error("settled market not cleaned up")
+				}
+			},
+		},
+		{
+			name:   "posterior sizing falls back to the prior for a thin bucket",
+			config: posterior,
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				record(l, WinRateKey{Side: "yes", Bucket: 85}, 10, 10)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				want := KellySizeAt(BayesianWinRate.Quantile(0.05), 85, 100_000)
+				if req := l.placed(t); req.Count != want {
+					t.Errorf("count = %d, want %d from the prior", req.Count, want)
+				}
+				if l.ms.WinRateKey != (WinRateKey{Side: "yes", Bucket: 85}) {
+					t.Errorf("WinRateKey = %+v, want yes/85", l.ms.WinRateKey)
+				}
+			},
+		},
+		{
+			name:   "posterior sizing skips an entry its seasoned bucket says loses",
+			config: posterior,
+			setup: func(t *testing.T, l *lifecycle) {
+				l.book(t, 82, 15)
+				record(l, WinRateKey{Side: "yes", Bucket: 85}, 20, 10)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 || l.ms.Traded {
+					t.Errorf("placed %+v, want no trade at a 67%% win rate", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name: "dry-run settlement leaves the posterior buckets alone",
+			config: func(c *config.Config) {
+				c.DryRun = true
+				c.BucketPosteriorPath = filepath.Join(c.VolDataDir, "buckets.json")
+			},
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("no", 88, 10)
+				l.ms.WinRateKey = WinRateKey{Side: "no", Bucket: 85}
+				l.closeIn(-time.Minute)
+				l.settle("yes", true)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if !l.ms.Settled || len(l.journal.Settlements()) != 1 {
+					t.Fatalf("settled %v, journal %v; want the paper settlement journaled", l.ms.Settled, l.journal.Events)
+				}
+				if n := l.e.winRates.Total(); n != 0 {
+					t.Errorf("buckets hold %d trades, want 0", n)
+				}
+				if _, err := os.Stat(l.e.cfg.BucketPosteriorPath); !os.IsNotExist(err) {
+					t.Errorf("bucket file written for a paper trade: %v", err)
+				}
+			},
+		},
+		{
+			name: "settlement updates the entry's posterior bucket",
+			config: func(c *config.Config) {
+				c.BucketPosteriorPath = filepath.Join(c.VolDataDir, "buckets.json")
+			},
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("no", 88, 10)
+				l.ms.WinRateKey = WinRateKey{Side: "no", Bucket: 85}
+				l.closeIn(-time.Minute)
+				l.settle("yes", true)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				loaded := NewWinRates(BayesianWinRate, 5, 1, 0)
+				if err := loaded.LoadFromFile(l.e.cfg.BucketPosteriorPath); err != nil {
+					t.Fatal(err)
+				}
+				key := WinRateKey{Side: "no", Bucket: 85}
+				if n := loaded.Observations(key); n != 1 {
+					t.Fatalf("saved bucket has %d observations, want 1", n)
+				}
+				if p, fromBucket := loaded.WinRate(key, 0.5); !fromBucket || p >= 0.5 {
+					t.Errorf("WinRate = %v (bucket %v), want a loss recorded", p, fromBucket)
+				}
+			},
+		},
+		{
+			name:   "book flip sells the position at the bid",
+			config: exitOnFlip,
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.book(t, 30, 60)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.orders.Execute(l.ms.ExitOrderID, 20, 30)
+				l.e.handleEvent(ctx, kalshi.StreamEvent{Kind: kalshi.EventOrder, Ticker: lifecycleTicker})
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				req := l.placed(t)
+				if req.Action != "sell" || req.Side != "yes" || req.YesPrice != 30 || req.Count != 20 || req.TimeInForce != "immediate_or_cancel" {
+					t.Errorf("order = %+v, want sell 20 YES @ 30 IOC", req)
+				}
+				trades := l.journal.Trades()
+				if len(trades) != 1 || trades[0].Action != "sell" || trades[0].Filled != 20 || trades[0].Price != 30 || trades[0].ExitReason != ExitBookFlip {
+					t.Fatalf("journaled %+v, want a book_flip sell of 20 @ 30", trades)
+				}
+				if l.ms.ExitPending || l.ms.ExitedContracts != 20 || l.ms.ExitProceeds != 600 || l.ms.ExitFeeCents != TakerFee(20, 30) {
+					t.Errorf("state = %+v, want fully exited", l.ms)
+				}
+			},
+		},
+		{
+			name: "exit rules off hold through a flip",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.book(t, 30, 60)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 || l.ms.ExitPending {
+					t.Errorf("placed %+v, want no exit", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name:   "no exit on a stale book over a silent connection",
+			config: exitOnFlip,
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.book(t, 30, 60)
+				l.market.Books[lifecycleTicker].LastUpdate = time.Now().Add(-2 * time.Minute)
+				l.market.Heard = time.Now().Add(-2 * time.Minute)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 {
+					t.Errorf("placed %+v, want no exit", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name:   "no exit in the last seconds before close",
+			config: exitOnFlip,
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.book(t, 30, 60)
+				l.closeIn(exitCutoff - time.Second)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Placed) != 0 {
+					t.Errorf("placed %+v, want no exit", l.orders.Placed)
+				}
+			},
+		},
+		{
+			name:   "partial exit is canceled and settles at local P&L",
+			config: exitOnFlip,
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.book(t, 30, 60)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.orders.Execute(l.ms.ExitOrderID, 5, 30)
+				l.ms.ExitPlacedAt = time.Now().Add(-exitOrderTimeout)
+				l.e.processMarket(ctx, l.ms)
+
+				l.closeIn(-time.Minute)
+				l.settle("yes", true)
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if len(l.orders.Cancels) != 1 || l.ms.ExitedContracts != 5 {
+					t.Fatalf("cancels %v, state %+v; want 5 exited", l.orders.Cancels, l.ms)
+				}
+				exitFee := TakerFee(5, 30)
+				want := PositionPnL(true, 85, 20, TakerFee(20, 85), 5, 150, exitFee)
+				sts := l.journal.Settlements()
+				if len(sts) != 1 || sts[0].PnLSource != "local" || sts[0].PnLCents != want || sts[0].ExitedContracts != 5 ||
+					sts[0].ExitProceedsCents != 150 || sts[0].FeeCents != TakerFee(20, 85)+exitFee {
+					t.Errorf("journaled %+v, want local P&L %d over 15 held", sts, want)
+				}
+			},
+		},
+		{
+			name: "stuck exit is given up and the position settled",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.ms.ExitPending, l.ms.ExitOrderID = true, "ord-lost"
+				l.closeIn(-exitResolveWait - time.Second)
+				l.settle("yes", true)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if sts := l.journal.Settlements(); len(sts) != 1 || sts[0].Contracts != 20 || !sts[0].Won {
+					t.Errorf("journaled %+v, want the 20 held settled", sts)
+				}
+				if l.ms.ExitPending || l.tracked() {
+					t.Errorf("state = %+v, want exit dropped and market cleaned up", l.ms)
+				}
+			},
+		},
+		{
+			name: "stuck exit does not block the settlement timeout",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.ms.ExitPending, l.ms.ExitOrderID = true, "ord-lost"
+				l.closeIn(-16 * time.Minute)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if !l.ms.Settled || l.tracked() {
+					t.Errorf("state = %+v, want timed out and cleaned up", l.ms)
+				}
+			},
+		},
+		{
+			name: "dry-run drawdown exit is simulated at the bid",
+			config: func(c *config.Config) {
+				c.DryRun = true
+				c.ExitMaxDrawdown = 0.25
+			},
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("no", 85, 10)
+				l.book(t, 40, 58)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				trades := l.journal.Trades()
+				if len(l.orders.Placed) != 0 || len(trades) != 1 || trades[0].OrderID != "dry-run" || trades[0].Price != 58 ||
+					trades[0].ExitReason != ExitDrawdown {
+					t.Fatalf("placed %v, journaled %+v; want a simulated drawdown sell @ 58", l.orders.Placed, trades)
+				}
+				if l.ms.ExitedContracts != 10 || l.ms.ExitPending {
+					t.Errorf("state = %+v", l.ms)
+				}
+			},
+		},
+		{
+			name: "lost settlement journals a loss",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("no", 88, 10)
+				l.closeIn(-time.Minute)
+				l.settle("yes", true)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				want := ComputePnL(false, 88, 10, TakerFee(10, 88))
+				if sts := l.journal.Settlements(); len(sts) != 1 || sts[0].Won || sts[0].PnLCents != want || sts[0].PnLMismatch {
+					t.Errorf("journaled %+v, want a loss of %d", sts, want)
+				}
+			},
+		},
+		{
+			name: "missing exchange record falls back to local P&L",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.closeIn(-5 * time.Minute)
+				l.settle("yes", false)
+				l.ms.ResultSeenAt = time.Now().Add(-settlementRecordWait - time.Second)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				want := ComputePnL(true, 85, 20, TakerFee(20, 85))
+				if sts := l.journal.Settlements(); len(sts) != 1 || sts[0].PnLSource != "local" || sts[0].PnLCents != want {
+					t.Errorf("journaled %+v, want local P&L %d", sts, want)
+				}
+			},
+		},
+		{
+			name: "late result is journaled at local P&L at the timeout",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.closeIn(-settlementTimeout - time.Second)
+				l.settle("yes", false)
+				l.ms.ResultSeenAt = time.Now().Add(-30 * time.Second)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				want := ComputePnL(true, 85, 20, TakerFee(20, 85))
+				if sts := l.journal.Settlements(); len(sts) != 1 || sts[0].PnLSource != "local" || sts[0].PnLCents != want {
+					t.Errorf("journaled %+v, want local P&L %d", sts, want)
+				}
+				if !l.ms.Settled || l.tracked() {
+					t.Errorf("state = %+v, want settled and cleaned up", l.ms)
+				}
+			},
+		},
+		{
+			name: "settlement polling gives up after 15 minutes",
+			setup: func(t *testing.T, l *lifecycle) {
+				l.holding("yes", 85, 20)
+				l.closeIn(-16 * time.Minute)
+			},
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				if !l.ms.Settled || l.tracked() || len(l.journal.Events) != 0 {
+					t.Errorf("settled %v, tracked %v, journal %v", l.ms.Settled, l.tracked(), l.journal.Events)
+				}
+			},
+		},
+		{
+			name:  "full lifecycle: signal, fill, close, settle",
+			setup: func(t *testing.T, l *lifecycle) { l.book(t, 82, 15) },
+			run: func(ctx context.Context, t *testing.T, l *lifecycle) {
+				l.e.processMarket(ctx, l.ms)
+				l.orders.Execute(l.orderID(t), size, 85)
+				l.e.processMarket(ctx, l.ms)
+
+				l.closeIn(-time.Minute)
+				l.e.processMarket(ctx, l.ms)
+				l.settle("yes", true)
+				l.e.handleEvent(ctx, kalshi.StreamEvent{
+					Kind:      kalshi.EventLifecycle,
+					Ticker:    lifecycleTicker,
+					Lifecycle: &kalshi.LifecycleEvent{Ticker: lifecycleTicker, EventType: "determined", Result: "yes"},
+				})
+			},
+			check: func(t *testing.T, l *lifecycle) {
+				trades, sts := l.journal.Trades(), l.journal.Settlements()
+				if len(l.journal.Events) != 2 || len(trades) != 1 || len(sts) != 1 {
+					t.Fatalf("journal = %+v, want a trade then a settlement", l.journal.Events)
+				}
+				if sts[0].Contracts != trades[0].Filled || sts[0].EntryPrice != 85 || sts[0].PnLSource != "exchange" || sts[0].PnLMismatch {
+					t.Errorf("settlement %+v does not match trade %+v", sts[0], trades[0])
+				}
+				if l.tracked() {
+					t.Error("market still tracked after settlement")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			l := newLifecycle(t, tt.config)
+			tt.setup(t, l)
+			tt.run(ctx, t, l)
+			tt.check(t, l)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package strategy
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// Strategy decides when and how to enter a market. Implementations are
+// registered by name in strategies and chosen per series in config
+// (STRATEGY, SERIES_STRATEGIES).
+type Strategy interface {
+	Name() string
+	// Window is when, relative to close, the strategy evaluates a market.
+	Window() EntryWindow
+	// Evaluate returns an entry signal, or a Signal with no Side to pass.
+	Evaluate(in Inputs) Signal
+}
+
+// Inputs is what a Strategy sees of a market on one tick.
+type Inputs struct {
+	Market     *MarketState           // strike, close time and entry state
+	Book       *kalshi.OrderbookState // fresh, with both a bid and an ask
+	Spot       float64                // latest underlying index price in dollars, 0 if unknown
+	UntilClose time.Duration
+
+	// TrackRecord is how many settled trades the engine has recorded (see
+	// WinRates.Total), for strategies that size flat until it is long enough.
+	TrackRecord int64
+}
+
+// EntryWindow bounds evaluation by time until close.
+type EntryWindow struct {
+	Open  time.Duration // evaluation starts this long before close
+	Close time.Duration // and stops this long before close
+	Once  bool          // evaluate on the first tick with a usable book only
+}
+
+// Contains reports whether untilClose is inside the window.
+func (w EntryWindow) Contains(untilClose time.Duration) bool {
+	return untilClose > w.Close && untilClose <= w.Open
+}
+
+// DefaultStrategy is used for series with no strategy configured.
+const DefaultStrategy = "80c"
+
+var strategies = map[string]func() Strategy{
+	"80c":      func() Strategy { return takerAt80{} },
+	"55c-4min": func() Strategy { return bidAt55{} },
+}
+
+// NewStrategy returns the registered strategy called name.
+func NewStrategy(name string) (Strategy, error) {
+	newStrategy, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q (have %v)", name, StrategyNames())
+	}
+	return newStrategy(), nil
+}
+
+// StrategyNames returns the registered strategy names, sorted.
+func StrategyNames() []string {
+	return slices.Sorted(maps.Keys(strategies))
+}
+
+// takerAt80 is the original rule (see Evaluate): buy the favored side at
+// the ask once it reaches 80c, rechecking every tick from 4:00 to 3:30
+// before close.
+type takerAt80 struct{}
+
+func (takerAt80) Name() string { return "80c" }
+
+func (takerAt80) Window() EntryWindow {
+	return EntryWindow{Open: entryWindowStart * time.Second, Close: entryWindowEnd * time.Second}
+}
+
+func (takerAt80) Evaluate(in Inputs) Signal {
+	sig := Evaluate(in.Book.BestYesBid(), in.Book.BestYesAsk())
+	if sig.Side != "" {
+		sig.Rationale = fmt.Sprintf("%s ask %dc >= 80c, taking at the ask", sig.Side, sig.RefAsk)
+	}
+	return sig
+}
+
+// bidAt55 is strategy-spec-55c-4min.md: evaluate once as the 4:00 mark
+// before close is crossed and, if the favored side's ask is 55c or more,
+// join its bid with a limit order.
+//
+// Sizing follows the spec: a flat contract until bidAt55ConfirmTrades
+// trades have settled, then quarter-Kelly capped by liquidity (see
+// liquidityCap), and never less than one contract.
+type bidAt55 struct{}
+
+// bidAt55 sizing limits from the spec.
+const (
+	bidAt55ConfirmTrades = 100    // settled trades before sizing past one contract
+	bidAt55MaxFillCents  = 300_00 // most spent on one entry ($50-$300 in the spec; we take the top)
+	bidAt55MaxBookShare  = 0.15   // largest share of the visible book one entry may take
+)
+
+func (bidAt55) Name() string { return "55c-4min" }
+
+// Window opens at 4:00 and allows 30s for a usable book, so a market first
+// seen later than that (e.g. after a restart) is skipped, not chased.
+func (bidAt55) Window() EntryWindow {
+	return EntryWindow{Open: 4 * time.Minute, Close: 4*time.Minute - 30*time.Second, Once: true}
+}
+
+func (bidAt55) Evaluate(in Inputs) Signal {
+	const threshold = 55
+	yesBid, yesAsk := in.Book.BestYesBid(), in.Book.BestYesAsk()
+
+	sig := Signal{MinSize: 1}
+	switch noAsk := 100 - yesBid; {
+	case yesAsk >= threshold:
+		sig.Side, sig.LimitPrice, sig.RefAsk = "yes", yesBid, yesAsk
+	case noAsk >= threshold:
+		sig.Side, sig.LimitPrice, sig.RefAsk = "no", 100-yesAsk, noAsk
+	default:
+		return Signal{}
+	}
+	sig.SizeHint = 1
+	if in.TrackRecord >= bidAt55ConfirmTrades {
+		sig.SizeHint = liquidityCap(in.Book, sig.Side, sig.LimitPrice, bidAt55MaxFillCents, bidAt55MaxBookShare)
+	}
+	sig.Rationale = fmt.Sprintf("%s ask %dc >= 55c, joining the bid at %dc", sig.Side, sig.RefAsk, sig.LimitPrice)
+	return sig
+}
+
+// liquidityCap returns the most contracts of side to buy at price: no more
+// than maxFillCents including fees, nor share of the contracts visible on
+// side's bids and asks. It is at least one contract.
+func liquidityCap(ob *kalshi.OrderbookState, side string, price, maxFillCents int, share float64) int {
+	depth := 0
+	for l := range ob.Bids(side) {
+		depth += l.Quantity
+	}
+	for l := range ob.Asks(side) {
+		depth += l.Quantity
+	}
+	cost := float64(price) + kalshi.MarginalTakerFee(price)
+	n := min(int(float64(maxFillCents)/cost), int(share*float64(depth)))
+	return max(1, n)
+}
@@ -0,0 +1,103 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// SeriesState holds per-series metadata and price feed. Each configured
+// series (KXBTC15M, KXETH15M, ...) gets its own vol filter reading that
+// series' data collector files.
+type SeriesState struct {
+	Ticker string
+	Info   *kalshi.Series // exchange metadata; nil until loadSeries succeeds
+
+	strategy      Strategy
+	volFilter     *VolFilter
+	lastVolUpdate time.Time
+}
+
+// defaultVolWindow is the vol filter window until the series' metadata says
+// how long its events run.
+const defaultVolWindow = 15 * time.Minute
+
+func newSeriesState(ticker string, strat Strategy, dataDir string, maxStdDev float64) *SeriesState {
+	return &SeriesState{
+		Ticker:    ticker,
+		strategy:  strat,
+		volFilter: NewSeriesVolFilter(dataDir, ticker, defaultVolWindow, maxStdDev),
+	}
+}
+
+// SettlementSource returns the name of the index the series settles
+// against, e.g. "CF Benchmarks BRTI", or "" if metadata isn't loaded.
+func (s *SeriesState) SettlementSource() string {
+	if s.Info == nil || len(s.Info.SettlementSources) == 0 {
+		return ""
+	}
+	return s.Info.SettlementSources[0].Name
+}
+
+// Owns reports whether a market ticker belongs to this series
+// (e.g. "KXBTC15M-26FEB101730-30" belongs to KXBTC15M).
+func (s *SeriesState) Owns(marketTicker string) bool {
+	return strings.HasPrefix(marketTicker, s.Ticker+"-")
+}
+
+// loadSeries fetches exchange metadata for every configured series and sizes
+// each vol filter's window to the series' event period. Failures are logged
+// and leave Info nil; the engine trades without it on the default window.
+func (e *Engine) loadSeries(ctx context.Context) {
+	for _, s := range e.series {
+		info, err := e.market.GetSeries(ctx, s.Ticker)
+		if err != nil {
+			slog.Warn("series metadata fetch failed", "series", s.Ticker, "err", err)
+			continue
+		}
+		s.Info = info
+		if period := info.Period(); period > 0 {
+			s.volFilter.SetWindow(period)
+		}
+		slog.Info("series loaded",
+			"series", s.Ticker,
+			"title", info.Title,
+			"frequency", info.Frequency,
+			"volWindow", s.volFilter.Window(),
+			"settlementSource", s.SettlementSource(),
+			"strategy", s.strategy.Name(),
+		)
+	}
+}
+
+// seriesFor returns the configured series owning a market ticker, or nil.
+func (e *Engine) seriesFor(marketTicker string) *SeriesState {
+	for _, s := range e.series {
+		if s.Owns(marketTicker) {
+			return s
+		}
+	}
+	return nil
+}
+
+// updateVolFilters refreshes each series' price feed every 10 seconds.
+func (e *Engine) updateVolFilters() {
+	for _, s := range e.series {
+		if time.Since(s.lastVolUpdate) <= 10*time.Second {
+			continue
+		}
+		if price := s.volFilter.Update(); price > 0 {
+			slog.Debug("vol_price_update",
+				"series", s.Ticker,
+				"price", fmt.Sprintf("$%.2f", price),
+				"stddev", fmt.Sprintf("$%.2f", s.volFilter.StdDev()),
+				"samples", s.volFilter.SampleCount(),
+			)
+		}
+		s.lastVolUpdate = time.Now()
+	}
+}
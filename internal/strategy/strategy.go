@@ -2,11 +2,13 @@ package strategy
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
 	"net/url"
-	"strings"
 	"sync"
 	"time"
 
@@ -20,15 +22,25 @@ type Signal struct {
 	Side       string // "yes", "no", or "" (no trade)
 	LimitPrice int    // price in cents to place limit order at
 	RefAsk     int    // the ask price that triggered the signal
+	SizeHint   int    // cap on contracts; 0 leaves sizing to Kelly
+	MinSize    int    // contracts to buy even when Kelly says no bet; 0 respects Kelly
+	Rationale  string // why the strategy fired, for logs
 }
 
 // MarketState tracks the lifecycle of a single market.
 type MarketState struct {
 	Ticker        string
+	Series        string // owning series ticker, e.g. "KXBTC15M"
 	Strike        float64
+	StrikeSpec    kalshi.StrikeSpec
 	StrikeFetched bool
 	CloseTime     time.Time // when trading ends (market closes)
+
+	// Book subscription — see followSubscription. Subscribed is set once
+	// the exchange acknowledges it; a rejection is retried.
 	Subscribed    bool
+	Subscribing   <-chan error // answer to the subscribe in flight, nil if none
+	LastSubscribe time.Time
 
 	// Entry state
 	Evaluated  bool // true once signal found or Kelly=0 (stop rechecking within window)
@@ -37,26 +49,55 @@ type MarketState struct {
 	EntryPrice int
 	Contracts  int
 	FeeCents   int
+	WinRateKey WinRateKey // posterior bucket the entry was sized from
+
+	// ExpectedPrice is the average entry price per contract expected from
+	// the book at placement, journaled against the realized fill price
+	ExpectedPrice float64
+
+	// Exit state — see managePosition. ExitedContracts of Contracts have
+	// been sold before close for ExitProceeds cents, before ExitFeeCents
+	ExitedContracts   int
+	ExitProceeds      int
+	ExitFeeCents      int
+	ExitReason        string
+	ExitPending       bool
+	ExitOrderID       string // "" while an ambiguous placement is resolved
+	ExitClientOrderID string
+	ExitAttempts      int
+	ExitPlacedAt      time.Time
+	LastExitLookup    time.Time
 
 	// Order management
 	OrderPending  bool
 	OrderID       string
 	OrderPlacedAt time.Time
 
+	// Ambiguous placement — CreateOrder's response was lost, so we don't know
+	// whether PendingOrder reached the book. Resolved by client_order_id lookup.
+	ClientOrderID    string
+	OrderUnconfirmed bool
+	PendingOrder     *kalshi.OrderRequest
+	OrderResends     int
+	LastOrderLookup  time.Time
+
 	// Settlement — polled from Kalshi API after market settles (~6min post-close)
 	Settled            bool
 	LastSettlementPoll time.Time
+	ResultSeenAt       time.Time // when the market result first appeared
 
 	// Rate limiting for strike fetch
 	LastStrikePoll time.Time
 }
 
-// Engine is the main trading engine for the BTC 15-min strategy.
+// Engine is the main trading engine. It trades every configured series
+// (KXBTC15M by default) with the same entry, sizing and settlement logic.
 type Engine struct {
-	client  *kalshi.Client
-	ws      *kalshi.WSClient
-	cfg     *config.Config
-	journal *journal.Journal
+	market    MarketDataSource
+	orders    OrderGateway
+	portfolio PortfolioReader
+	journal   EventSink
+	cfg       *config.Config
 
 	markets map[string]*MarketState
 	mu      sync.Mutex
@@ -65,20 +106,49 @@ type Engine struct {
 	lastBalanceSync time.Time
 	lastDiscovery   time.Time
 
-	volFilter     *VolFilter
-	lastVolUpdate time.Time
+	series    []*SeriesState
+	winRates  *WinRates
+	exitRules ExitRules
+
+	// Exchange trading gate — see updateTradingGate
+	halted           bool
+	lastStatusPoll   time.Time
+	schedule         *kalshi.ExchangeSchedule
+	lastSchedulePoll time.Time
 }
 
-// NewEngine creates a new strategy engine.
-func NewEngine(client *kalshi.Client, ws *kalshi.WSClient, cfg *config.Config, j *journal.Journal) *Engine {
-	return &Engine{
-		client:    client,
-		ws:        ws,
+// NewEngine creates a new strategy engine. Use LiveDeps to trade against
+// the exchange. It fails if a series is configured with an unknown
+// strategy.
+func NewEngine(deps Deps, cfg *config.Config) (*Engine, error) {
+	e := &Engine{
+		market:    deps.Market,
+		orders:    deps.Orders,
+		portfolio: deps.Portfolio,
+		journal:   deps.Journal,
 		cfg:       cfg,
-		journal:   j,
 		markets:   make(map[string]*MarketState),
-		volFilter: NewVolFilter(cfg.VolDataDir, 15*time.Minute, cfg.VolMaxStdDev),
+		exitRules: NewExitRules(cfg),
+	}
+	for _, ticker := range cfg.Series {
+		name := cfg.StrategyFor(ticker)
+		if name == "" {
+			name = DefaultStrategy
+		}
+		strat, err := NewStrategy(name)
+		if err != nil {
+			return nil, fmt.Errorf("series %s: %w", ticker, err)
+		}
+		e.series = append(e.series, newSeriesState(ticker, strat, cfg.VolDataDir, cfg.VolMaxStdDev))
 	}
+
+	e.winRates = NewWinRates(BayesianWinRate, cfg.PosteriorBucketCents, cfg.PosteriorMinObs, cfg.PosteriorVolSplit)
+	if cfg.BucketPosteriorPath != "" {
+		if err := e.winRates.LoadFromFile(cfg.BucketPosteriorPath); err != nil {
+			return nil, fmt.Errorf("loading bucket posteriors: %w", err)
+		}
+	}
+	return e, nil
 }
 
 // Evaluate determines whether to trade based on orderbook prices.
@@ -101,18 +171,28 @@ func Evaluate(yesBid, yesAsk int) Signal {
 // Window: 4:00 to 3:30 before market close (secsUntilClose 210–240).
 // Backtest: 100% WR within 30s window; beyond 30s, losses appear.
 func InEntryWindow(secsUntilClose float64) bool {
-	return secsUntilClose > 210 && secsUntilClose <= 240
+	return secsUntilClose > entryWindowEnd && secsUntilClose <= entryWindowStart
 }
 
+// Entry window bounds in seconds until close.
+const (
+	entryWindowStart = 240
+	entryWindowEnd   = 210
+)
+
 // BayesianWinRate tracks posterior distribution of true win rate.
 // Initialized with Beta(83, 3) from Feb 10-12 backtest (82W/2L).
 // Updated nightly with new trades to adapt to regime changes.
 var BayesianWinRate = NewBayesianPosterior()
 
+// fixedWinRate is the win rate KellySize assumes: a conservative estimate
+// from 22W/3L observed at tradeable prices.
+const fixedWinRate = 0.92
+
 // KellySize computes the quarter-Kelly contract count per the strategy spec.
 // Uses fixed 0.92 assumed win rate (conservative estimate from 22W/3L observed
 // at tradeable prices). Naturally blocks entries >=92c where risk/reward is
-// terrible. Will switch to Bayesian posterior once we have 100+ observations.
+// terrible. See KellySizeAt and WinRates for sizing from the posterior.
 //
 //	fee         = 0.07 * min(entry, 100-entry)  (per contract, in cents)
 //	win_profit  = 100 - entry - fee
@@ -124,12 +204,23 @@ var BayesianWinRate = NewBayesianPosterior()
 //
 // Returns 0 if Kelly says no bet. Minimum 1 contract.
 func KellySize(limitPrice, balanceCents int) int {
+	return KellySizeAt(fixedWinRate, limitPrice, balanceCents)
+}
+
+// KellySizeAt is KellySize with win rate p.
+func KellySizeAt(p float64, limitPrice, balanceCents int) int {
+	entry := float64(limitPrice)
+	return kellySize(p, limitPrice, balanceCents, 0.07*math.Min(entry, 100-entry))
+}
+
+// kellySize is the quarter-Kelly contract count at limitPrice paying fee
+// cents per contract.
+func kellySize(p float64, limitPrice, balanceCents int, fee float64) int {
 	if limitPrice <= 0 || limitPrice >= 100 || balanceCents <= 0 {
 		return 0
 	}
 
 	entry := float64(limitPrice)
-	fee := 0.07 * math.Min(entry, 100-entry)
 	winProfit := 100 - entry - fee
 	lossAmount := entry + fee
 
@@ -137,7 +228,6 @@ func KellySize(limitPrice, balanceCents int) int {
 		return 0
 	}
 
-	const p = 0.92
 	q := 1 - p
 	b := winProfit / lossAmount
 	kelly := p - (q / b)
@@ -156,13 +246,9 @@ func KellySize(limitPrice, balanceCents int) int {
 	return contracts
 }
 
-// TakerFee computes the Kalshi taker fee in cents.
-// fee = ceil(0.07 * contracts * P * (1-P) * 100)
-// where P = priceCents / 100
+// TakerFee computes the Kalshi taker fee in cents; see kalshi.TakerFee.
 func TakerFee(contracts, priceCents int) int {
-	p := float64(priceCents) / 100.0
-	fee := 0.07 * float64(contracts) * p * (1 - p) * 100.0
-	return int(math.Ceil(fee))
+	return kalshi.TakerFee(contracts, priceCents)
 }
 
 // ComputePnL computes the P&L in cents for a settled position.
@@ -175,7 +261,20 @@ func ComputePnL(won bool, entryPrice, contracts, feeCents int) int {
 	return -(entryPrice*contracts + feeCents)
 }
 
-// reconcilePositions queries the Kalshi API for existing BTC15M positions
+// PositionPnL is ComputePnL for a position of which exited contracts were
+// sold before settlement for exitProceeds cents, paying exitFee:
+//
+//	pnl = exitProceeds - exitFee + (won ? 100 * held : 0) - entry * contracts - entryFee
+//	held = contracts - exited
+func PositionPnL(won bool, entryPrice, contracts, entryFee, exited, exitProceeds, exitFee int) int {
+	pnl := exitProceeds - exitFee - entryPrice*contracts - entryFee
+	if won {
+		pnl += 100 * (contracts - exited)
+	}
+	return pnl
+}
+
+// reconcilePositions queries the Kalshi API for existing positions in the configured series
 // and pre-populates the markets map so the engine doesn't re-trade on restart.
 func (e *Engine) reconcilePositions(ctx context.Context) {
 	if e.cfg.DryRun {
@@ -183,7 +282,7 @@ func (e *Engine) reconcilePositions(ctx context.Context) {
 		return
 	}
 
-	positions, err := e.client.GetPositions(ctx, "")
+	positions, err := e.portfolio.GetPositions(ctx, "")
 	if err != nil {
 		slog.Error("position reconciliation failed", "err", err)
 		return
@@ -191,11 +290,15 @@ func (e *Engine) reconcilePositions(ctx context.Context) {
 
 	reconciled := 0
 	for _, pos := range positions {
-		if pos.Position == 0 || !strings.HasPrefix(pos.Ticker, "KXBTC15M-") {
+		if pos.Position == 0 {
+			continue
+		}
+		series := e.seriesFor(pos.Ticker)
+		if series == nil {
 			continue
 		}
 
-		m, err := e.client.GetMarket(ctx, pos.Ticker)
+		m, err := e.market.GetMarket(ctx, pos.Ticker)
 		if err != nil {
 			slog.Warn("reconcile: failed to get market", "ticker", pos.Ticker, "err", err)
 			continue
@@ -224,16 +327,19 @@ func (e *Engine) reconcilePositions(ctx context.Context) {
 		avgPrice, fee := e.reconstructEntry(ctx, pos.Ticker, side, contracts)
 
 		ms := &MarketState{
-			Ticker:        pos.Ticker,
-			CloseTime:     closeTime,
-			Strike:        m.StrikePrice(),
-			StrikeFetched: m.StrikePrice() > 0,
-			Evaluated:     true,
-			Traded:        true,
-			Side:          side,
-			EntryPrice:    avgPrice,
-			Contracts:     contracts,
-			FeeCents:      fee,
+			Ticker:     pos.Ticker,
+			Series:     series.Ticker,
+			CloseTime:  closeTime,
+			Evaluated:  true,
+			Traded:     true,
+			Side:       side,
+			EntryPrice: avgPrice,
+			Contracts:  contracts,
+			FeeCents:   fee,
+		}
+
+		if err := applyStrike(ms, m); err != nil {
+			slog.Warn("reconcile: strike unavailable", "ticker", pos.Ticker, "err", err)
 		}
 
 		e.mu.Lock()
@@ -241,11 +347,7 @@ func (e *Engine) reconcilePositions(ctx context.Context) {
 		e.mu.Unlock()
 
 		// Subscribe to WS if market is still open (needed for settlement polling after close)
-		if err := e.ws.Subscribe([]string{pos.Ticker}); err != nil {
-			slog.Warn("reconcile: ws subscribe failed", "ticker", pos.Ticker, "err", err)
-		} else {
-			ms.Subscribed = true
-		}
+		e.subscribe(ms)
 
 		slog.Info("reconciled position",
 			"ticker", pos.Ticker,
@@ -266,7 +368,7 @@ func (e *Engine) reconstructEntry(ctx context.Context, ticker, side string, cont
 	params := url.Values{}
 	params.Set("ticker", ticker)
 
-	fills, _, err := e.client.GetFills(ctx, params)
+	fills, err := e.portfolio.GetFills(ctx, params)
 	if err != nil {
 		slog.Warn("reconcile: failed to get fills", "ticker", ticker, "err", err)
 		return 0, 0
@@ -299,11 +401,20 @@ func (e *Engine) reconstructEntry(ctx context.Context, ticker, side string, cont
 
 // Run starts the engine's main loop with a 1-second ticker.
 func (e *Engine) Run(ctx context.Context) error {
+	e.loadSeries(ctx)
 	e.reconcilePositions(ctx)
 
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	// Account and lifecycle pushes are handled as they arrive; books are
+	// still read on each tick.
+	events, stopEvents := e.market.Events(kalshi.EventFilter{
+		Kinds:  []kalshi.EventKind{kalshi.EventFill, kalshi.EventOrder, kalshi.EventLifecycle},
+		Policy: kalshi.DropOldest,
+	})
+	defer stopEvents()
+
 	slog.Info("strategy engine started")
 
 	for {
@@ -312,6 +423,8 @@ func (e *Engine) Run(ctx context.Context) error {
 			return ctx.Err()
 		case <-ticker.C:
 			e.tick(ctx)
+		case ev := <-events:
+			e.handleEvent(ctx, ev)
 		}
 	}
 }
@@ -319,26 +432,20 @@ func (e *Engine) Run(ctx context.Context) error {
 func (e *Engine) tick(ctx context.Context) {
 	// Refresh balance every 60 seconds
 	if time.Since(e.lastBalanceSync) > 60*time.Second {
-		if bal, err := e.client.GetBalance(ctx); err == nil {
+		if bal, err := e.portfolio.GetBalance(ctx); err == nil {
 			e.balance = bal.Balance
 			e.lastBalanceSync = time.Now()
 		}
 	}
 
-	// Update volatility filter every 10 seconds
-	if time.Since(e.lastVolUpdate) > 10*time.Second {
-		if price := e.volFilter.Update(); price > 0 {
-			slog.Debug("vol_price_update",
-				"brti", fmt.Sprintf("$%.2f", price),
-				"stddev", fmt.Sprintf("$%.2f", e.volFilter.StdDev()),
-				"samples", e.volFilter.SampleCount(),
-			)
-		}
-		e.lastVolUpdate = time.Now()
-	}
+	// Update volatility filters every 10 seconds
+	e.updateVolFilters()
 
-	// Discover new markets every 30 seconds
-	if time.Since(e.lastDiscovery) > 30*time.Second {
+	// Suspend discovery and entries while the exchange is halted
+	e.updateTradingGate(ctx)
+
+	// Discover new markets every 30 seconds, or at once when one is created
+	if !e.halted && time.Since(e.lastDiscovery) > 30*time.Second {
 		e.discoverMarkets(ctx)
 		e.lastDiscovery = time.Now()
 	}
@@ -364,10 +471,50 @@ func (e *Engine) tick(ctx context.Context) {
 	}
 }
 
+// handleEvent reacts to a pushed WS event between ticks. A new market in a
+// traded series triggers discovery on the next tick instead of waiting for
+// the 30s poll; a fill or order update re-checks the pending order at once;
+// a determination polls settlement at once.
+func (e *Engine) handleEvent(ctx context.Context, ev kalshi.StreamEvent) {
+	e.mu.Lock()
+	ms := e.markets[ev.Ticker]
+	e.mu.Unlock()
+
+	switch ev.Kind {
+	case kalshi.EventLifecycle:
+		switch ev.Lifecycle.EventType {
+		case "created", "activated":
+			if ms == nil {
+				slog.Info("market lifecycle: new market", "ticker", ev.Ticker, "event", ev.Lifecycle.EventType)
+				e.lastDiscovery = time.Time{}
+			}
+		case "determined":
+			slog.Info("market lifecycle: determined", "ticker", ev.Ticker, "result", ev.Lifecycle.Result)
+			if ms != nil && ms.Traded && !ms.Settled && time.Until(ms.CloseTime) <= 0 {
+				e.pollSettlement(ctx, ms)
+			}
+		}
+
+	case kalshi.EventFill, kalshi.EventOrder:
+		if ms != nil && ms.OrderPending && !ms.OrderUnconfirmed {
+			e.checkOrderStatus(ctx, ms)
+		}
+		if ms != nil && ms.ExitPending && ms.ExitOrderID != "" {
+			e.checkExitStatus(ctx, ms)
+		}
+	}
+}
+
 func (e *Engine) discoverMarkets(ctx context.Context) {
-	markets, err := e.client.GetMarkets(ctx, "KXBTC15M", "open")
+	for _, s := range e.series {
+		e.discoverSeriesMarkets(ctx, s)
+	}
+}
+
+func (e *Engine) discoverSeriesMarkets(ctx context.Context, series *SeriesState) {
+	markets, err := e.market.GetMarkets(ctx, series.Ticker, "open")
 	if err != nil {
-		slog.Warn("market discovery failed", "err", err)
+		slog.Warn("market discovery failed", "series", series.Ticker, "err", err)
 		return
 	}
 
@@ -393,14 +540,13 @@ func (e *Engine) discoverMarkets(ctx context.Context) {
 
 		ms := &MarketState{
 			Ticker:    m.Ticker,
+			Series:    series.Ticker,
 			CloseTime: closeTime,
 		}
 
-		// Try to get strike immediately
-		strike := m.StrikePrice()
-		if strike > 0 {
-			ms.Strike = strike
-			ms.StrikeFetched = true
+		// Try to get strike immediately — often not published until the market opens
+		if err := applyStrike(ms, &m); err != nil {
+			slog.Debug("strike not yet available", "ticker", m.Ticker, "err", err)
 		}
 
 		e.mu.Lock()
@@ -411,30 +557,80 @@ func (e *Engine) discoverMarkets(ctx context.Context) {
 			"ticker", m.Ticker,
 			"closeTime", closeTime.Format(time.RFC3339),
 			"secsUntilClose", int(secsUntilClose),
-			"strike", strike,
+			"strike", ms.Strike,
 		)
 
 		// Subscribe to WS orderbook
-		if err := e.ws.Subscribe([]string{m.Ticker}); err != nil {
-			slog.Warn("ws subscribe failed", "ticker", m.Ticker, "err", err)
-		} else {
-			ms.Subscribed = true
+		e.subscribe(ms)
+	}
+}
+
+// subscribeRetryInterval is how long after a subscribe attempt a rejected
+// one is retried.
+const subscribeRetryInterval = 10 * time.Second
+
+// subscribe asks the exchange to stream ms's book; followSubscription
+// picks up the answer.
+func (e *Engine) subscribe(ms *MarketState) {
+	ms.LastSubscribe = time.Now()
+	ms.Subscribing = e.market.SubscribeAsync([]string{ms.Ticker})
+	e.followSubscription(ms)
+}
+
+// followSubscription marks ms subscribed once the exchange acknowledges
+// its subscribe, and resubscribes every subscribeRetryInterval after a
+// rejection or timeout. It never waits for an answer.
+func (e *Engine) followSubscription(ms *MarketState) {
+	if ms.Subscribed {
+		return
+	}
+	if ms.Subscribing != nil {
+		select {
+		case err := <-ms.Subscribing:
+			ms.Subscribing = nil
+			if err == nil {
+				ms.Subscribed = true
+				return
+			}
+			slog.Warn("ws subscribe failed", "ticker", ms.Ticker, "err", err)
+		default:
+			return
 		}
 	}
+	if time.Since(ms.LastSubscribe) >= subscribeRetryInterval {
+		e.subscribe(ms)
+	}
+}
+
+// applyStrike parses the market's settlement condition into ms.
+func applyStrike(ms *MarketState, m *kalshi.Market) error {
+	spec, err := kalshi.ParseStrike(m)
+	if err != nil {
+		return err
+	}
+	ms.StrikeSpec = spec
+	ms.Strike = spec.Strike()
+	ms.StrikeFetched = true
+	return nil
 }
 
 func (e *Engine) processMarket(ctx context.Context, ms *MarketState) {
 	secsUntilClose := time.Until(ms.CloseTime).Seconds()
+	e.followSubscription(ms)
 
 	// Fetch strike if not yet fetched (every 10s)
 	if !ms.StrikeFetched && time.Since(ms.LastStrikePoll) > 10*time.Second {
 		ms.LastStrikePoll = time.Now()
-		if m, err := e.client.GetMarket(ctx, ms.Ticker); err == nil {
-			strike := m.StrikePrice()
-			if strike > 0 {
-				ms.Strike = strike
-				ms.StrikeFetched = true
-				slog.Info("strike fetched", "ticker", ms.Ticker, "strike", strike)
+		if m, err := e.market.GetMarket(ctx, ms.Ticker); err == nil {
+			if err := applyStrike(ms, m); err != nil {
+				slog.Warn("strike parse failed", "ticker", ms.Ticker, "err", err, "rules", m.RulesPrimary)
+			} else {
+				slog.Info("strike fetched",
+					"ticker", ms.Ticker,
+					"strike", ms.Strike,
+					"type", ms.StrikeSpec.Type,
+					"index", ms.StrikeSpec.Index,
+				)
 			}
 		}
 	}
@@ -452,15 +648,32 @@ func (e *Engine) processMarket(ctx context.Context, ms *MarketState) {
 		return
 	}
 
-	// Skip if signal already found, already traded, or outside the 30s entry window.
-	// Window: 4:00→3:30 before close. Recheck every tick until signal or window expires.
-	if ms.Evaluated || ms.Traded || !InEntryWindow(secsUntilClose) {
+	// Hold or exit an open position until close
+	if ms.Traded {
+		e.managePosition(ctx, ms)
 		return
 	}
 
-	// Volatility filter: block trading when BTC price stddev is too high
-	if !e.volFilter.IsSafe() {
-		stddev := e.volFilter.StdDev()
+	series := e.seriesFor(ms.Ticker)
+	if series == nil {
+		return
+	}
+
+	// Skip if signal already found, already traded, or outside the strategy's
+	// entry window. Recheck every tick until signal or window expires.
+	untilClose := time.Until(ms.CloseTime)
+	window := series.strategy.Window()
+	if ms.Evaluated || ms.Traded || !window.Contains(untilClose) {
+		return
+	}
+
+	if e.halted {
+		return
+	}
+
+	// Volatility filter: block trading when the underlying's price stddev is too high
+	if !series.volFilter.IsSafe() {
+		stddev := series.volFilter.StdDev()
 		slog.Warn("vol_filter_blocked",
 			"ticker", ms.Ticker,
 			"stddev", fmt.Sprintf("$%.2f", stddev),
@@ -477,11 +690,19 @@ func (e *Engine) processMarket(ctx context.Context, ms *MarketState) {
 	}
 
 	// Get orderbook from WS — retry next tick if not yet available
-	ob := e.ws.GetOrderbook(ms.Ticker)
+	ob := e.market.GetOrderbook(ms.Ticker)
 	if ob == nil {
 		slog.Warn("evaluation deferred - orderbook not available", "ticker", ms.Ticker)
 		return
 	}
+	if age, stale := e.bookStale(ob); stale {
+		slog.Warn("evaluation deferred - orderbook stale",
+			"ticker", ms.Ticker,
+			"age", age.Round(time.Second),
+			"maxAge", e.cfg.MaxBookAge,
+		)
+		return
+	}
 
 	yesBid := ob.BestYesBid()
 	yesAsk := ob.BestYesAsk()
@@ -490,31 +711,118 @@ func (e *Engine) processMarket(ctx context.Context, ms *MarketState) {
 		return // orderbook empty — recheck next tick within window
 	}
 
-	// Evaluate signal — recheck each tick within the 30s window
-	sig := Evaluate(yesBid, yesAsk)
+	// Evaluate signal — recheck each tick within the window unless the
+	// strategy evaluates once
+	sig := series.strategy.Evaluate(Inputs{
+		Market:     ms,
+		Book:       ob,
+		Spot:       series.volFilter.Price(),
+		UntilClose: untilClose,
+
+		TrackRecord: e.winRates.Total(),
+	})
+	if window.Once {
+		ms.Evaluated = true
+	}
 	if sig.Side == "" {
+		if window.Once {
+			slog.Info("no signal", "ticker", ms.Ticker, "strategy", series.strategy.Name(), "yesBid", yesBid, "yesAsk", yesAsk)
+		}
 		return // no signal yet — recheck next tick within window
 	}
 
+	// Model probability of YES from the index's distance to the strike
+	fair, fairOK := e.fairValue(series, ms, untilClose)
+	edgeCents := sideEdge(fair, sig.Side, sig.LimitPrice)
+	fairValue, edge := "n/a", "n/a"
+	if fairOK {
+		fairValue = fmt.Sprintf("%.3f", fair)
+		edge = fmt.Sprintf("%.1f", edgeCents)
+	}
+
+	if e.cfg.FairValueFilter && (!fairOK || edgeCents < e.cfg.FairValueMinEdge) {
+		slog.Info("signal skipped - edge over fair value too small",
+			"ticker", ms.Ticker,
+			"strategy", series.strategy.Name(),
+			"side", sig.Side,
+			"limitPrice", sig.LimitPrice,
+			"fairValue", fairValue,
+			"edge", edge,
+			"minEdge", e.cfg.FairValueMinEdge,
+			"spot", series.volFilter.Price(),
+			"strike", ms.Strike,
+			"secsUntilClose", int(secsUntilClose),
+		)
+		return // recheck next tick within window
+	}
+
 	// Signal found — stop rechecking
 	ms.Evaluated = true
 
 	slog.Info("signal detected",
 		"ticker", ms.Ticker,
+		"strategy", series.strategy.Name(),
 		"side", sig.Side,
 		"limitPrice", sig.LimitPrice,
 		"refAsk", sig.RefAsk,
+		"sizeHint", sig.SizeHint,
+		"rationale", sig.Rationale,
+		"fairValue", fairValue,
+		"edge", edge,
 		"secsUntilClose", int(secsUntilClose),
 		"strike", ms.Strike,
-		"vol_stddev", fmt.Sprintf("$%.2f", e.volFilter.StdDev()),
+		"spot", series.volFilter.Price(),
+		"vol_stddev", fmt.Sprintf("$%.2f", series.volFilter.StdDev()),
+		"spread", ob.Spread(),
+		"microprice", fmt.Sprintf("%.1f", ob.Microprice()),
+		"imbalance", fmt.Sprintf("%.2f", ob.Imbalance(3)),
+		"depthAtLimit", ob.CumulativeSize(sig.Side, sig.LimitPrice),
 	)
 
 	// Place order
-	e.placeOrder(ctx, ms, sig)
+	e.placeOrder(ctx, ms, sig, ob)
+}
+
+// bookStale reports whether ob may be out of date: neither it nor the
+// connection streaming it has been heard from in MaxBookAge. A quiet book
+// on a live connection is current, since every change to it is pushed.
+func (e *Engine) bookStale(ob *kalshi.OrderbookState) (time.Duration, bool) {
+	if e.cfg.MaxBookAge <= 0 {
+		return 0, false
+	}
+	last := ob.LastUpdate
+	if heard := e.market.LastHeard(); heard.After(last) {
+		last = heard
+	}
+	age := time.Since(last)
+	return age, age > e.cfg.MaxBookAge
 }
 
-func (e *Engine) placeOrder(ctx context.Context, ms *MarketState, sig Signal) {
-	contracts := KellySize(sig.LimitPrice, e.balance)
+// placeOrder sizes and sends the entry for sig against book ob. Taker
+// signals (a limit at the ask) are sized from depth when DepthSizing is on,
+// with the limit raised to the worst level the sweep takes.
+func (e *Engine) placeOrder(ctx context.Context, ms *MarketState, sig Signal, ob *kalshi.OrderbookState) {
+	var volStdDev float64
+	if series := e.seriesFor(ms.Ticker); series != nil {
+		volStdDev = series.volFilter.StdDev()
+	}
+	key := e.winRates.Key(sig.Side, sig.LimitPrice, volStdDev)
+	p, pSource := e.winRate(key)
+	ms.WinRateKey = key
+
+	sweepBook := e.cfg.DepthSizing && ob != nil && sig.LimitPrice >= sig.RefAsk
+	var contracts int
+	if sweepBook {
+		contracts = DepthSize(ob, sig.Side, p, e.balance, e.cfg.MaxSlippageCents)
+	} else {
+		contracts = KellySizeAt(p, sig.LimitPrice, e.balance)
+	}
+	if contracts == 0 && e.balance > 0 {
+		contracts = sig.MinSize
+	}
+	if sig.SizeHint > 0 {
+		contracts = min(contracts, sig.SizeHint)
+	}
 	if contracts == 0 {
 		slog.Info("kelly says no trade",
 			"ticker", ms.Ticker,
@@ -522,25 +830,35 @@ func (e *Engine) placeOrder(ctx context.Context, ms *MarketState, sig Signal) {
 			"limitPrice", sig.LimitPrice,
 			"refAsk", sig.RefAsk,
 			"balance", e.balance,
+			"winRate", fmt.Sprintf("%.3f", p),
+			"winRateSource", pSource,
 		)
 		return
 	}
 
-	fee := TakerFee(contracts, sig.LimitPrice)
+	limitPrice := sig.LimitPrice
+	fee := TakerFee(contracts, limitPrice)
+	expected := float64(limitPrice)
+	if sweepBook {
+		sweep := ob.SweepCost(sig.Side, contracts)
+		limitPrice, fee, expected = sweep.WorstPrice, sweep.Fee, sweep.AvgPrice()
+	}
+	ms.ExpectedPrice = expected
 
 	if e.cfg.DryRun {
-		// Dry run: simulate immediate fill at limit price
+		// Dry run: simulate immediate fill at the expected price
+		price := int(math.Round(expected))
 		ms.Traded = true
 		ms.Side = sig.Side
-		ms.EntryPrice = sig.LimitPrice
+		ms.EntryPrice = price
 		ms.Contracts = contracts
 		ms.FeeCents = fee
 
 		if err := e.journal.Log(journal.NewTrade(
 			ms.Ticker, sig.Side, "buy",
-			sig.LimitPrice, contracts, fee,
-			"dry-run", contracts, true, sig.LimitPrice,
-		)); err != nil {
+			price, contracts, fee,
+			"dry-run", contracts, true, limitPrice,
+		).WithExecution(expected, expected)); err != nil {
 			slog.Error("failed to journal dry-run trade",
 				"ticker", ms.Ticker,
 				"err", err,
@@ -550,33 +868,56 @@ func (e *Engine) placeOrder(ctx context.Context, ms *MarketState, sig Signal) {
 		slog.Info("dry-run trade",
 			"ticker", ms.Ticker,
 			"side", sig.Side,
-			"price", sig.LimitPrice,
+			"price", price,
+			"limitPrice", limitPrice,
 			"contracts", contracts,
 			"fee", fee,
 			"balance", e.balance,
+			"winRate", fmt.Sprintf("%.3f", p),
+			"winRateSource", pSource,
 		)
 		return
 	}
 
 	// Real order
 	req := kalshi.OrderRequest{
-		Ticker:      ms.Ticker,
-		Action:      "buy",
-		Side:        sig.Side,
-		Type:        "limit",
-		Count:       contracts,
-		TimeInForce: "good_till_canceled",
+		Ticker:        ms.Ticker,
+		ClientOrderID: ClientOrderID(ms.Ticker, "buy", sig.Side, limitPrice),
+		Action:        "buy",
+		Side:          sig.Side,
+		Type:          "limit",
+		Count:         contracts,
+		TimeInForce:   "good_till_canceled",
 	}
 
 	if sig.Side == "yes" {
-		req.YesPrice = sig.LimitPrice
+		req.YesPrice = limitPrice
 	} else {
-		req.NoPrice = sig.LimitPrice
+		req.NoPrice = limitPrice
 	}
 
-	order, err := e.client.CreateOrder(ctx, req)
+	ms.ClientOrderID = req.ClientOrderID
+
+	order, err := e.orders.CreateOrder(ctx, req)
 	if err != nil {
-		slog.Error("order placement failed", "ticker", ms.Ticker, "err", err)
+		if kalshi.IsRejected(err) {
+			slog.Error("order placement failed", "ticker", ms.Ticker, "err", err)
+			return
+		}
+
+		// The request may have reached the exchange. Don't re-send blindly —
+		// look the order up by client_order_id first (see resolveUnconfirmedOrder).
+		slog.Warn("order placement ambiguous — resolving by client order id",
+			"ticker", ms.Ticker,
+			"clientOrderID", req.ClientOrderID,
+			"err", err,
+		)
+		ms.OrderPending = true
+		ms.OrderUnconfirmed = true
+		ms.PendingOrder = &req
+		ms.OrderPlacedAt = time.Now()
+		ms.Side = sig.Side
+		ms.FeeCents = fee
 		return
 	}
 
@@ -590,112 +931,274 @@ func (e *Engine) placeOrder(ctx context.Context, ms *MarketState, sig Signal) {
 		"ticker", ms.Ticker,
 		"orderID", order.OrderID,
 		"side", sig.Side,
-		"price", sig.LimitPrice,
+		"price", limitPrice,
+		"expectedAvg", fmt.Sprintf("%.2f", expected),
 		"contracts", contracts,
+		"winRate", fmt.Sprintf("%.3f", p),
+		"winRateSource", pSource,
 	)
 }
 
-func (e *Engine) checkOrderStatus(ctx context.Context, ms *MarketState) {
-	elapsed := time.Since(ms.OrderPlacedAt)
+// winRate returns the win rate to size an entry in bucket key with, and
+// where it came from: "fixed" unless KellyPosterior is set, else "bucket"
+// or "prior" (see WinRates.WinRate).
+func (e *Engine) winRate(key WinRateKey) (float64, string) {
+	if !e.cfg.KellyPosterior {
+		return fixedWinRate, "fixed"
+	}
+	p, fromBucket := e.winRates.WinRate(key, e.cfg.KellyQuantile)
+	if fromBucket {
+		return p, "bucket " + key.String()
+	}
+	return p, "prior"
+}
 
-	if elapsed < 30*time.Second {
-		return // not yet timed out
+// ClientOrderID derives a stable client_order_id for an order. The same
+// market and signal always yield the same ID, so a re-send after a lost
+// response is deduplicated by the exchange instead of doubling the position.
+func ClientOrderID(ticker, action, side string, price int) string {
+	return hashOrderID(fmt.Sprintf("%s|%s|%s|%d", ticker, action, side, price))
+}
+
+// ExitClientOrderID derives the client_order_id of the attempt'th exit
+// sell of a position. Like ClientOrderID it is stable for re-sends of one
+// attempt, but each new attempt gets a fresh ID so a retry at the same
+// price isn't rejected as a duplicate of an earlier, finished exit.
+func ExitClientOrderID(ticker, side string, price, attempt int) string {
+	return hashOrderID(fmt.Sprintf("%s|sell|%s|%d|exit-%d", ticker, side, price, attempt))
+}
+
+// hashOrderID formats a hash of key as a UUID.
+func hashOrderID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	h := hex.EncodeToString(sum[:16])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// maxOrderResends caps how many times an unconfirmed order is re-sent after
+// the exchange reports no order under its client_order_id.
+const maxOrderResends = 2
+
+// resolveUnconfirmedOrder settles an ambiguous placement: if the exchange
+// has the order we adopt its ID, otherwise we re-send the identical request.
+func (e *Engine) resolveUnconfirmedOrder(ctx context.Context, ms *MarketState) {
+	if time.Since(ms.LastOrderLookup) < 2*time.Second {
+		return
 	}
+	ms.LastOrderLookup = time.Now()
 
-	// Check fills
-	params := url.Values{}
-	params.Set("ticker", ms.Ticker)
-	params.Set("order_id", ms.OrderID)
+	order, err := e.orders.GetOrderByClientID(ctx, ms.Ticker, ms.ClientOrderID)
+	switch {
+	case err == nil:
+		e.confirmOrder(ms, order.OrderID, "lookup")
+		return
+	case !errors.Is(err, kalshi.ErrOrderNotFound):
+		slog.Warn("order lookup failed", "ticker", ms.Ticker, "clientOrderID", ms.ClientOrderID, "err", err)
+		return
+	}
 
-	fills, _, err := e.client.GetFills(ctx, params)
-	if err != nil {
-		slog.Warn("fill check failed", "ticker", ms.Ticker, "err", err)
+	if ms.OrderResends >= maxOrderResends {
+		slog.Error("order unconfirmed — giving up",
+			"ticker", ms.Ticker,
+			"clientOrderID", ms.ClientOrderID,
+			"resends", ms.OrderResends,
+		)
+		ms.OrderPending = false
+		ms.OrderUnconfirmed = false
+		ms.PendingOrder = nil
 		return
 	}
 
-	totalFilled := 0
-	totalCost := 0
-	for _, f := range fills {
-		totalFilled += f.Count
-		if f.Side == "yes" {
-			totalCost += f.Count * f.YesPrice
-		} else {
-			totalCost += f.Count * f.NoPrice
+	ms.OrderResends++
+	order, err = e.orders.CreateOrder(ctx, *ms.PendingOrder)
+	switch {
+	case err == nil:
+		e.confirmOrder(ms, order.OrderID, "resend")
+	case kalshi.IsDuplicateOrder(err):
+		// The original did land; it just wasn't visible yet. Look again.
+		slog.Info("order resend deduplicated by exchange", "ticker", ms.Ticker, "clientOrderID", ms.ClientOrderID)
+	case kalshi.IsRejected(err):
+		slog.Error("order resend rejected", "ticker", ms.Ticker, "err", err)
+		ms.OrderPending = false
+		ms.OrderUnconfirmed = false
+		ms.PendingOrder = nil
+	default:
+		slog.Warn("order resend ambiguous", "ticker", ms.Ticker, "resends", ms.OrderResends, "err", err)
+	}
+}
+
+func (e *Engine) confirmOrder(ms *MarketState, orderID, via string) {
+	ms.OrderID = orderID
+	ms.OrderUnconfirmed = false
+	ms.PendingOrder = nil
+	slog.Info("order confirmed",
+		"ticker", ms.Ticker,
+		"orderID", orderID,
+		"clientOrderID", ms.ClientOrderID,
+		"via", via,
+	)
+}
+
+func (e *Engine) checkOrderStatus(ctx context.Context, ms *MarketState) {
+	if ms.OrderUnconfirmed {
+		e.resolveUnconfirmedOrder(ctx, ms)
+		return
+	}
+
+	// A final user_orders push is authoritative; otherwise poll the order
+	// every 2s, or at once when a fill for it arrives on the WS feed
+	order, pushed := e.orders.GetOrderUpdate(ms.OrderID)
+	if !pushed || !order.IsFinal() {
+		freshFill := e.orders.LastFillTime(ms.OrderID).After(ms.LastOrderLookup)
+		if !freshFill && time.Since(ms.LastOrderLookup) < 2*time.Second {
+			return
+		}
+		ms.LastOrderLookup = time.Now()
+
+		var err error
+		order, err = e.orders.GetOrder(ctx, ms.OrderID)
+		if err != nil {
+			slog.Warn("order status check failed", "ticker", ms.Ticker, "orderID", ms.OrderID, "err", err)
+			return
 		}
 	}
 
-	if totalFilled > 0 {
-		// Order filled
-		avgPrice := totalCost / totalFilled
-		ms.OrderPending = false
-		ms.Traded = true
-		ms.EntryPrice = avgPrice
-		ms.Contracts = totalFilled
-		ms.FeeCents = TakerFee(totalFilled, avgPrice)
+	if !order.IsFinal() {
+		if time.Since(ms.OrderPlacedAt) < 30*time.Second {
+			return // still working
+		}
 
-		if err := e.journal.Log(journal.NewTrade(
-			ms.Ticker, ms.Side, "buy",
-			avgPrice, totalFilled, ms.FeeCents,
-			ms.OrderID, totalFilled, false, avgPrice,
-		)); err != nil {
-			slog.Error("failed to journal trade",
-				"ticker", ms.Ticker,
-				"err", err,
-			)
+		// Not fully filled after 30s — cancel the remainder
+		canceled, err := e.orders.CancelOrder(ctx, ms.OrderID)
+		if err != nil {
+			slog.Warn("order cancel failed", "ticker", ms.Ticker, "err", err)
+			return
 		}
+		slog.Info("order cancelled after 30s",
+			"ticker", ms.Ticker,
+			"filled", canceled.FilledCount,
+			"cancelled", canceled.RemainingCount,
+		)
+		order = canceled
+	}
+
+	ms.OrderPending = false
+	e.orders.ForgetOrder(ms.OrderID)
+
+	if order.FilledCount == 0 {
+		slog.Info("order closed unfilled", "ticker", ms.Ticker, "status", order.Status)
+		return
+	}
+
+	avgPrice := order.AvgFillPrice()
+	realized := float64(avgPrice)
+	if cost := order.TakerFillCost + order.MakerFillCost; cost > 0 {
+		realized = float64(cost) / float64(order.FilledCount)
+	}
+	fee := order.FeesPaid()
+	if fee == 0 {
+		fee = TakerFee(order.FilledCount, avgPrice)
+	}
+
+	ms.Traded = true
+	ms.EntryPrice = avgPrice
+	ms.Contracts = order.FilledCount
+	ms.FeeCents = fee
 
-		slog.Info("order filled",
+	if err := e.journal.Log(journal.NewTrade(
+		ms.Ticker, ms.Side, "buy",
+		avgPrice, order.FilledCount, ms.FeeCents,
+		ms.OrderID, order.FilledCount, false, order.LimitPrice(),
+	).WithExecution(ms.ExpectedPrice, realized)); err != nil {
+		slog.Error("failed to journal trade",
 			"ticker", ms.Ticker,
-			"side", ms.Side,
-			"avgPrice", avgPrice,
-			"filled", totalFilled,
+			"err", err,
 		)
-	} else {
-		// Not filled after 30s — cancel
-		if err := e.client.CancelOrder(ctx, ms.OrderID); err != nil {
-			slog.Warn("order cancel failed", "ticker", ms.Ticker, "err", err)
-		} else {
-			slog.Info("order cancelled (unfilled after 30s)", "ticker", ms.Ticker)
-		}
-		ms.OrderPending = false
 	}
+
+	slog.Info("order filled",
+		"ticker", ms.Ticker,
+		"side", ms.Side,
+		"avgPrice", avgPrice,
+		"expectedAvg", fmt.Sprintf("%.2f", ms.ExpectedPrice),
+		"realizedAvg", fmt.Sprintf("%.2f", realized),
+		"filled", order.FilledCount,
+		"status", order.Status,
+	)
 }
 
+// settlementRecordWait is how long after a market result appears we wait for
+// the portfolio settlement record before journaling local P&L only.
+const settlementRecordWait = 2 * time.Minute
+
+// settlementTimeout is how long after close we keep polling for a result.
+// Past it nothing more is waited for: a known result is journaled at local
+// P&L, and a market with none is given up on.
+const settlementTimeout = 15 * time.Minute
+
 // pollSettlement checks the Kalshi API for the market's settlement result.
 // Markets settle ~6 minutes after close. We poll every 10 seconds until the
-// result field is populated ("yes" or "no"), then compute P&L.
+// result field is populated ("yes" or "no"), then compute P&L. In live mode
+// the exchange's portfolio settlement record is authoritative; local P&L is
+// journaled next to it and flagged when the two disagree.
 func (e *Engine) pollSettlement(ctx context.Context, ms *MarketState) {
-	// Rate limit: poll every 10 seconds
-	if time.Since(ms.LastSettlementPoll) < 10*time.Second {
+	// Rate limit: poll every 10 seconds, unless the WS feed just pushed
+	// the market's determination
+	determined := e.market.MarketResult(ms.Ticker) != ""
+	if time.Since(ms.LastSettlementPoll) < 10*time.Second && !(determined && ms.ResultSeenAt.IsZero()) {
 		return
 	}
 	ms.LastSettlementPoll = time.Now()
-
-	// Bail out after 15 minutes of polling (something is wrong)
-	if time.Since(ms.CloseTime) > 15*time.Minute {
-		slog.Error("settlement timeout — gave up polling after 15 min",
-			"ticker", ms.Ticker,
-		)
-		ms.Settled = true
-		e.cleanupMarket(ms)
-		return
+	timedOut := time.Since(ms.CloseTime) > settlementTimeout
+
+	// An exit still working at close is accounted for before settling. If
+	// it can't be resolved in time, settle what we last knew we held.
+	if ms.ExitPending {
+		e.checkExitStatus(ctx, ms)
+		if ms.ExitPending && time.Since(ms.CloseTime) < exitResolveWait {
+			return
+		}
+		if ms.ExitPending {
+			slog.Warn("exit order unresolved after close — settling remaining position",
+				"ticker", ms.Ticker,
+				"orderID", ms.ExitOrderID,
+				"clientOrderID", ms.ExitClientOrderID,
+				"held", ms.Contracts-ms.ExitedContracts,
+			)
+			ms.ExitPending = false
+		}
 	}
 
-	m, err := e.client.GetMarket(ctx, ms.Ticker)
-	if err != nil {
+	// Result is empty until Kalshi settles the market. At the timeout a
+	// failed poll falls back to the pushed determination.
+	var result string
+	m, err := e.market.GetMarket(ctx, ms.Ticker)
+	switch {
+	case err == nil:
+		result = m.Result
+	case timedOut:
+		slog.Warn("settlement poll failed", "ticker", ms.Ticker, "err", err)
+		result = e.market.MarketResult(ms.Ticker)
+	default:
 		slog.Warn("settlement poll failed", "ticker", ms.Ticker, "err", err)
 		return
 	}
-
-	// Result is empty until Kalshi settles the market
-	if m.Result == "" {
+	if result == "" {
+		if timedOut {
+			slog.Error("settlement timeout — gave up polling after 15 min",
+				"ticker", ms.Ticker,
+			)
+			ms.Settled = true
+			e.cleanupMarket(ms)
+			return
+		}
 		sinceClosed := time.Since(ms.CloseTime).Round(time.Second)
 		slog.Debug("awaiting settlement", "ticker", ms.Ticker, "sinceClose", sinceClosed)
 		return
 	}
 
 	// Market settled — result is "yes" or "no"
-	yesResolved := m.Result == "yes"
+	yesResolved := result == "yes"
 
 	var sideWon bool
 	if ms.Side == "yes" {
@@ -704,13 +1207,55 @@ func (e *Engine) pollSettlement(ctx context.Context, ms *MarketState) {
 		sideWon = !yesResolved
 	}
 
-	pnl := ComputePnL(sideWon, ms.EntryPrice, ms.Contracts, ms.FeeCents)
+	pnl := PositionPnL(sideWon, ms.EntryPrice, ms.Contracts, ms.FeeCents,
+		ms.ExitedContracts, ms.ExitProceeds, ms.ExitFeeCents)
 	won := pnl > 0
 
-	if err := e.journal.Log(journal.NewSettlement(
-		ms.Ticker, ms.Strike, 0, won, pnl, ms.FeeCents,
+	entry := journal.NewSettlement(
+		ms.Ticker, ms.Strike, 0, won, pnl, ms.FeeCents+ms.ExitFeeCents,
 		ms.Side, ms.EntryPrice, ms.Contracts, nil, e.cfg.DryRun,
-	)); err != nil {
+	)
+	if ms.ExitedContracts > 0 {
+		entry = entry.WithExit(ms.ExitedContracts, ms.ExitProceeds)
+	}
+
+	// The exchange's settlement record covers only contracts held to
+	// settlement, so a position with exits is journaled at local P&L
+	if !e.cfg.DryRun && ms.ExitedContracts == 0 {
+		if ms.ResultSeenAt.IsZero() {
+			ms.ResultSeenAt = time.Now()
+		}
+
+		st, err := e.exchangeSettlement(ctx, ms.Ticker)
+		switch {
+		case err != nil:
+			slog.Warn("settlement record fetch failed", "ticker", ms.Ticker, "err", err)
+		case st != nil:
+			entry = entry.WithExchange(st.Revenue, st.FeeCents(), st.YesCount, st.NoCount, st.PnL())
+		}
+
+		if st == nil && time.Since(ms.ResultSeenAt) < settlementRecordWait && !timedOut {
+			slog.Debug("awaiting settlement record", "ticker", ms.Ticker)
+			return
+		}
+		if st == nil {
+			slog.Warn("no exchange settlement record — journaling local P&L", "ticker", ms.Ticker)
+		}
+	}
+
+	if entry.PnLMismatch {
+		slog.Warn("settlement P&L mismatch",
+			"ticker", ms.Ticker,
+			"exchangePnl", entry.PnLCents,
+			"localPnl", entry.LocalPnLCents,
+			"exchangeFees", entry.ExchangeFeeCents,
+			"localFees", ms.FeeCents,
+		)
+	}
+	pnl = entry.PnLCents
+	won = entry.Won
+
+	if err := e.journal.Log(entry); err != nil {
 		slog.Error("failed to journal settlement - will retry",
 			"ticker", ms.Ticker,
 			"err", err,
@@ -721,21 +1266,66 @@ func (e *Engine) pollSettlement(ctx context.Context, ms *MarketState) {
 	slog.Info("settlement",
 		"ticker", ms.Ticker,
 		"side", ms.Side,
-		"result", m.Result,
+		"result", result,
 		"sideWon", sideWon,
 		"won", won,
 		"pnl", fmt.Sprintf("$%.2f", float64(pnl)/100.0),
+		"pnlSource", entry.PnLSource,
 		"entry", ms.EntryPrice,
 		"contracts", ms.Contracts,
+		"exited", ms.ExitedContracts,
 		"waitTime", time.Since(ms.CloseTime).Round(time.Second),
 	)
 
+	e.observeWinRate(ms, sideWon)
+
 	ms.Settled = true
 	e.cleanupMarket(ms)
 }
 
+// observeWinRate adds a settled entry to its posterior bucket and persists
+// the buckets. Positions adopted by reconcilePositions have no bucket and
+// are skipped, as are dry-run entries: live sizing and the 55c track
+// record read these buckets, and paper fills are not evidence.
+func (e *Engine) observeWinRate(ms *MarketState, sideWon bool) {
+	if ms.WinRateKey.Side == "" || e.cfg.DryRun {
+		return
+	}
+	e.winRates.Observe(ms.WinRateKey, sideWon)
+	if e.cfg.BucketPosteriorPath == "" {
+		return
+	}
+	if err := e.winRates.SaveToFile(e.cfg.BucketPosteriorPath); err != nil {
+		slog.Error("failed to save bucket posteriors", "path", e.cfg.BucketPosteriorPath, "err", err)
+	}
+}
+
+// exchangeSettlement returns the portfolio settlement record for ticker, or
+// nil if the exchange hasn't published it yet.
+func (e *Engine) exchangeSettlement(ctx context.Context, ticker string) (*kalshi.Settlement, error) {
+	params := url.Values{}
+	params.Set("ticker", ticker)
+
+	settlements, err := e.portfolio.GetSettlements(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range settlements {
+		if settlements[i].Ticker == ticker {
+			return &settlements[i], nil
+		}
+	}
+	return nil, nil
+}
+
 func (e *Engine) cleanupMarket(ms *MarketState) {
-	e.ws.Unsubscribe([]string{ms.Ticker})
+	// Nothing here depends on the answer; just report a failure
+	result := e.market.UnsubscribeAsync([]string{ms.Ticker})
+	go func() {
+		if err := <-result; err != nil {
+			slog.Warn("ws unsubscribe failed", "ticker", ms.Ticker, "err", err)
+		}
+	}()
 
 	e.mu.Lock()
 	delete(e.markets, ms.Ticker)
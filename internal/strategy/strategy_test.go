@@ -1,7 +1,13 @@
 package strategy
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/config"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
 )
 
 func TestEvaluate(t *testing.T) {
@@ -339,3 +345,173 @@ func TestComputePnL(t *testing.T) {
 		})
 	}
 }
+
+func TestClientOrderID(t *testing.T) {
+	a := ClientOrderID("KXBTC15M-26FEB101730-30", "buy", "yes", 85)
+	if b := ClientOrderID("KXBTC15M-26FEB101730-30", "buy", "yes", 85); a != b {
+		t.Errorf("ClientOrderID not deterministic: %q vs %q", a, b)
+	}
+	if len(a) != 36 {
+		t.Errorf("ClientOrderID length = %d, want 36 (UUID form)", len(a))
+	}
+
+	others := []string{
+		ClientOrderID("KXBTC15M-26FEB101745-45", "buy", "yes", 85),
+		ClientOrderID("KXBTC15M-26FEB101730-30", "buy", "no", 85),
+		ClientOrderID("KXBTC15M-26FEB101730-30", "buy", "yes", 86),
+		ClientOrderID("KXBTC15M-26FEB101730-30", "sell", "yes", 85),
+	}
+	for _, o := range others {
+		if o == a {
+			t.Errorf("ClientOrderID collision for distinct inputs: %q", o)
+		}
+	}
+}
+
+func TestExitClientOrderID(t *testing.T) {
+	const ticker = "KXBTC15M-26FEB101730-30"
+	a := ExitClientOrderID(ticker, "yes", 40, 0)
+	if b := ExitClientOrderID(ticker, "yes", 40, 0); a != b {
+		t.Errorf("ExitClientOrderID not deterministic: %q vs %q", a, b)
+	}
+	if a == ExitClientOrderID(ticker, "yes", 40, 1) {
+		t.Error("retry attempt reuses the first attempt's ID")
+	}
+	if a == ClientOrderID(ticker, "sell", "yes", 40) {
+		t.Error("exit ID collides with ClientOrderID")
+	}
+}
+
+func TestSeriesOwns(t *testing.T) {
+	btc := newSeriesState("KXBTC15M", takerAt80{}, "/nonexistent", 200)
+	tests := []struct {
+		ticker string
+		want   bool
+	}{
+		{"KXBTC15M-26FEB101730-30", true},
+		{"KXETH15M-26FEB101730-30", false},
+		{"KXBTC15MX-26FEB101730-30", false},
+		{"KXBTC15M", false},
+	}
+	for _, tt := range tests {
+		if got := btc.Owns(tt.ticker); got != tt.want {
+			t.Errorf("Owns(%q) = %v, want %v", tt.ticker, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSeriesSizesVolWindow(t *testing.T) {
+	l := newLifecycle(t, func(cfg *config.Config) {
+		cfg.Series = []string{"KXBTC15M", "KXBTCD", "KXBTCW", "KXBTCX"}
+	})
+	l.market.Series["KXBTC15M"] = &kalshi.Series{Ticker: "KXBTC15M", Frequency: "fifteen_min"}
+	l.market.Series["KXBTCD"] = &kalshi.Series{Ticker: "KXBTCD", Frequency: "hourly"}
+	l.market.Series["KXBTCW"] = &kalshi.Series{Ticker: "KXBTCW", Frequency: "custom"}
+	l.e.loadSeries(context.Background())
+
+	want := map[string]time.Duration{
+		"KXBTC15M": 15 * time.Minute,
+		"KXBTCD":   time.Hour,
+		"KXBTCW":   defaultVolWindow, // no fixed period
+		"KXBTCX":   defaultVolWindow, // metadata fetch failed
+	}
+	for _, s := range l.e.series {
+		if got := s.volFilter.Window(); got != want[s.Ticker] {
+			t.Errorf("%s vol window = %v, want %v", s.Ticker, got, want[s.Ticker])
+		}
+	}
+}
+
+func TestBidAt55Evaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		yesBid    int
+		noBid     int
+		wantSide  string
+		wantLimit int
+		wantRef   int
+	}{
+		{"yes ask 57: join YES bid", 55, 43, "yes", 55, 57},
+		{"yes ask exactly 55", 53, 45, "yes", 53, 55},
+		{"no ask 58: join NO bid", 42, 52, "no", 52, 58},
+		{"no trade: both asks below 55", 48, 48, "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob, err := kalshi.NewOrderbook("T", []kalshi.PriceLevel{{Price: tt.yesBid, Quantity: 10}}, []kalshi.PriceLevel{{Price: tt.noBid, Quantity: 10}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig := bidAt55{}.Evaluate(Inputs{Book: ob})
+			if sig.Side != tt.wantSide || sig.LimitPrice != tt.wantLimit || sig.RefAsk != tt.wantRef {
+				t.Errorf("signal = %+v, want %s limit %d ref %d", sig, tt.wantSide, tt.wantLimit, tt.wantRef)
+			}
+			if sig.Side != "" && (sig.SizeHint != 1 || sig.MinSize != 1 || sig.Rationale == "") {
+				t.Errorf("signal = %+v, want a flat contract with a rationale", sig)
+			}
+		})
+	}
+}
+
+func TestBidAt55LiquidityCap(t *testing.T) {
+	tests := []struct {
+		name        string
+		depth       int // contracts on each side of the book
+		trackRecord int64
+		want        int
+	}{
+		{"flat until the track record is confirmed", 1000, 99, 1},
+		{"15% of a thin book", 20, 100, 6},
+		{"capped by the max fill", 5000, 100, 528},
+		{"at least one contract", 2, 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob, err := kalshi.NewOrderbook("T", []kalshi.PriceLevel{{Price: 55, Quantity: tt.depth}}, []kalshi.PriceLevel{{Price: 43, Quantity: tt.depth}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig := bidAt55{}.Evaluate(Inputs{Book: ob, TrackRecord: tt.trackRecord})
+			if sig.SizeHint != tt.want {
+				t.Errorf("SizeHint = %d, want %d", sig.SizeHint, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntryWindowContains(t *testing.T) {
+	tests := []struct {
+		strategy   string
+		untilClose time.Duration
+		want       bool
+	}{
+		{"80c", 241 * time.Second, false},
+		{"80c", 240 * time.Second, true},
+		{"80c", 211 * time.Second, true},
+		{"80c", 210 * time.Second, false},
+		{"55c-4min", 4*time.Minute + time.Second, false},
+		{"55c-4min", 4 * time.Minute, true},
+		{"55c-4min", 211 * time.Second, true},
+		{"55c-4min", 210 * time.Second, false},
+	}
+	for _, tt := range tests {
+		s, err := NewStrategy(tt.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Window().Contains(tt.untilClose); got != tt.want {
+			t.Errorf("%s: Contains(%v) = %v, want %v", tt.strategy, tt.untilClose, got, tt.want)
+		}
+	}
+}
+
+func TestNewEngineRejectsUnknownStrategy(t *testing.T) {
+	cfg := &config.Config{
+		Series:           []string{"KXBTC15M", "KXETH15M"},
+		Strategy:         "80c",
+		SeriesStrategies: map[string]string{"KXETH15M": "70c"},
+	}
+	if _, err := NewEngine(Deps{}, cfg); err == nil || !strings.Contains(err.Error(), "KXETH15M") {
+		t.Errorf("err = %v, want unknown strategy for KXETH15M", err)
+	}
+}
@@ -0,0 +1,412 @@
+// Package strategytest provides in-memory fakes of the strategy engine's
+// dependencies (strategy.MarketDataSource, OrderGateway, PortfolioReader
+// and EventSink) for unit tests. Each fake is scripted through its exported
+// fields and records what the engine did with it. The fakes are not safe
+// for concurrent use; drive the engine from the test goroutine.
+//
+// For end-to-end tests over the wire, see kalshitest.
+package strategytest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sdibella/kalshi-btc15m/internal/journal"
+	"github.com/sdibella/kalshi-btc15m/internal/kalshi"
+)
+
+// MarketData is a fake strategy.MarketDataSource.
+type MarketData struct {
+	Status   kalshi.ExchangeStatus
+	Schedule kalshi.ExchangeSchedule
+	Series   map[string]*kalshi.Series
+	Markets  map[string]*kalshi.Market
+	Books    map[string]*kalshi.OrderbookState
+	Results  map[string]string // pushed determinations, see MarketResult
+	Heard    time.Time         // returned by LastHeard; zero means now
+	Err      error             // returned by every REST call when set
+
+	Subscribed map[string]bool  // tickers currently subscribed
+	Reject     map[string]error // subscribe answers for tickers the exchange refuses
+	MarketGets int              // GetMarket calls
+
+	events []chan kalshi.StreamEvent
+}
+
+// NewMarketData returns a trading exchange with no markets.
+func NewMarketData() *MarketData {
+	return &MarketData{
+		Status:     kalshi.ExchangeStatus{ExchangeActive: true, TradingActive: true},
+		Series:     make(map[string]*kalshi.Series),
+		Markets:    make(map[string]*kalshi.Market),
+		Books:      make(map[string]*kalshi.OrderbookState),
+		Results:    make(map[string]string),
+		Subscribed: make(map[string]bool),
+		Reject:     make(map[string]error),
+	}
+}
+
+// SetBook streams a book for ticker, updated now.
+func (d *MarketData) SetBook(ticker string, yes, no []kalshi.PriceLevel) error {
+	ob, err := kalshi.NewOrderbook(ticker, yes, no)
+	if err != nil {
+		return err
+	}
+	ob.LastUpdate = time.Now()
+	d.Books[ticker] = ob
+	return nil
+}
+
+// Push delivers ev to every open Events stream.
+func (d *MarketData) Push(ev kalshi.StreamEvent) {
+	for _, ch := range d.events {
+		ch <- ev
+	}
+}
+
+func (d *MarketData) GetExchangeStatus(context.Context) (*kalshi.ExchangeStatus, error) {
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	status := d.Status
+	return &status, nil
+}
+
+func (d *MarketData) GetExchangeSchedule(context.Context) (*kalshi.ExchangeSchedule, error) {
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	schedule := d.Schedule
+	return &schedule, nil
+}
+
+func (d *MarketData) GetSeries(_ context.Context, seriesTicker string) (*kalshi.Series, error) {
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	s, ok := d.Series[seriesTicker]
+	if !ok {
+		return nil, notFound("series " + seriesTicker)
+	}
+	return s, nil
+}
+
+// GetMarkets returns the series' markets with status, sorted by ticker.
+func (d *MarketData) GetMarkets(_ context.Context, seriesTicker, status string) ([]kalshi.Market, error) {
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	var out []kalshi.Market
+	for _, m := range d.Markets {
+		if strings.HasPrefix(m.Ticker, seriesTicker+"-") && (status == "" || m.Status == status) {
+			out = append(out, *m)
+		}
+	}
+	slices.SortFunc(out, func(a, b kalshi.Market) int { return strings.Compare(a.Ticker, b.Ticker) })
+	return out, nil
+}
+
+func (d *MarketData) GetMarket(_ context.Context, ticker string) (*kalshi.Market, error) {
+	d.MarketGets++
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	m, ok := d.Markets[ticker]
+	if !ok {
+		return nil, notFound("market " + ticker)
+	}
+	out := *m
+	return &out, nil
+}
+
+// SubscribeAsync subscribes tickers, answering at once with the first
+// Reject error among them, in which case none is subscribed.
+func (d *MarketData) SubscribeAsync(tickers []string) <-chan error {
+	result := make(chan error, 1)
+	for _, t := range tickers {
+		if err := d.Reject[t]; err != nil {
+			result <- err
+			return result
+		}
+	}
+	for _, t := range tickers {
+		d.Subscribed[t] = true
+	}
+	result <- nil
+	return result
+}
+
+func (d *MarketData) UnsubscribeAsync(tickers []string) <-chan error {
+	for _, t := range tickers {
+		delete(d.Subscribed, t)
+	}
+	result := make(chan error, 1)
+	result <- nil
+	return result
+}
+
+// GetOrderbook returns a copy of the book set for a subscribed ticker.
+func (d *MarketData) GetOrderbook(ticker string) *kalshi.OrderbookState {
+	ob := d.Books[ticker]
+	if ob == nil || !d.Subscribed[ticker] {
+		return nil
+	}
+	out := *ob
+	return &out
+}
+
+func (d *MarketData) MarketResult(ticker string) string {
+	return d.Results[ticker]
+}
+
+// LastHeard returns Heard, or now if it is unset: a live connection.
+func (d *MarketData) LastHeard() time.Time {
+	if d.Heard.IsZero() {
+		return time.Now()
+	}
+	return d.Heard
+}
+
+// Events returns a buffered stream fed by Push. The filter is ignored.
+func (d *MarketData) Events(kalshi.EventFilter) (<-chan kalshi.StreamEvent, func()) {
+	ch := make(chan kalshi.StreamEvent, 64)
+	d.events = append(d.events, ch)
+	return ch, func() {
+		d.events = slices.DeleteFunc(d.events, func(c chan kalshi.StreamEvent) bool { return c == ch })
+	}
+}
+
+// Orders is a fake strategy.OrderGateway. Created orders rest unfilled
+// unless OnCreate says otherwise; tests fill them with Execute or by
+// editing Orders directly.
+type Orders struct {
+	Orders  map[string]*kalshi.Order // by order ID
+	Pushed  map[string]*kalshi.Order // latest user_orders push by order ID
+	Fills   map[string]time.Time     // last fill push by order ID
+	Placed  []kalshi.OrderRequest    // every CreateOrder request, in order
+	Cancels []string                 // every CancelOrder order ID, in order
+	Lookups int                      // GetOrder and GetOrderByClientID calls
+
+	// OnCreate, if set, decides CreateOrder's outcome. It may return an
+	// order (recorded as placed), an error, or both, as when the request
+	// reached the exchange but the response was lost.
+	OnCreate func(req kalshi.OrderRequest) (*kalshi.Order, error)
+
+	nextID int
+}
+
+// NewOrders returns a gateway with no orders.
+func NewOrders() *Orders {
+	return &Orders{
+		Orders: make(map[string]*kalshi.Order),
+		Pushed: make(map[string]*kalshi.Order),
+		Fills:  make(map[string]time.Time),
+	}
+}
+
+// Rest builds the resting order the exchange would create for req.
+func (g *Orders) Rest(req kalshi.OrderRequest) *kalshi.Order {
+	g.nextID++
+	return &kalshi.Order{
+		OrderID:        fmt.Sprintf("ord-%d", g.nextID),
+		ClientOrderID:  req.ClientOrderID,
+		Ticker:         req.Ticker,
+		Status:         "resting",
+		Action:         req.Action,
+		Side:           req.Side,
+		Type:           req.Type,
+		YesPrice:       req.YesPrice,
+		NoPrice:        req.NoPrice,
+		InitialCount:   req.Count,
+		RemainingCount: req.Count,
+	}
+}
+
+// Execute fills count contracts of a known order at price as a taker and
+// pushes the update, as the exchange would. The order is executed once
+// nothing remains.
+func (g *Orders) Execute(orderID string, count, price int) *kalshi.Order {
+	o := g.Orders[orderID]
+	count = min(count, o.RemainingCount)
+	o.FilledCount += count
+	o.RemainingCount -= count
+	o.TakerFillCost += count * price
+	o.TakerFees += kalshi.TakerFee(count, price)
+	if o.RemainingCount == 0 {
+		o.Status = "executed"
+	}
+	g.push(o)
+	g.Fills[orderID] = time.Now()
+	return o
+}
+
+func (g *Orders) push(o *kalshi.Order) {
+	pushed := *o
+	g.Pushed[o.OrderID] = &pushed
+}
+
+func (g *Orders) CreateOrder(_ context.Context, req kalshi.OrderRequest) (*kalshi.Order, error) {
+	g.Placed = append(g.Placed, req)
+	var o *kalshi.Order
+	var err error
+	if g.OnCreate != nil {
+		o, err = g.OnCreate(req)
+	} else {
+		o = g.Rest(req)
+	}
+	if o != nil {
+		g.Orders[o.OrderID] = o
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := *o
+	return &out, nil
+}
+
+func (g *Orders) GetOrder(_ context.Context, orderID string) (*kalshi.Order, error) {
+	g.Lookups++
+	o, ok := g.Orders[orderID]
+	if !ok {
+		return nil, notFound("order " + orderID)
+	}
+	out := *o
+	return &out, nil
+}
+
+func (g *Orders) GetOrderByClientID(_ context.Context, ticker, clientOrderID string) (*kalshi.Order, error) {
+	g.Lookups++
+	for _, o := range g.Orders {
+		if o.Ticker == ticker && o.ClientOrderID == clientOrderID {
+			out := *o
+			return &out, nil
+		}
+	}
+	return nil, kalshi.ErrOrderNotFound
+}
+
+// CancelOrder cancels a resting order's remainder; other orders are not
+// found, as on the exchange.
+func (g *Orders) CancelOrder(_ context.Context, orderID string) (*kalshi.Order, error) {
+	g.Cancels = append(g.Cancels, orderID)
+	o, ok := g.Orders[orderID]
+	if !ok || o.Status != "resting" {
+		return nil, notFound("order " + orderID)
+	}
+	o.Status = "canceled"
+	o.RemainingCount = 0
+	g.push(o)
+	canceled := *o
+	return &canceled, nil
+}
+
+func (g *Orders) GetOrderUpdate(orderID string) (*kalshi.Order, bool) {
+	o, ok := g.Pushed[orderID]
+	if !ok {
+		return nil, false
+	}
+	out := *o
+	return &out, true
+}
+
+func (g *Orders) LastFillTime(orderID string) time.Time {
+	return g.Fills[orderID]
+}
+
+func (g *Orders) ForgetOrder(orderID string) {
+	delete(g.Pushed, orderID)
+	delete(g.Fills, orderID)
+}
+
+// Portfolio is a fake strategy.PortfolioReader.
+type Portfolio struct {
+	Balance     int
+	Positions   []kalshi.Position
+	Fills       []kalshi.Fill
+	Settlements []kalshi.Settlement
+	Err         error // returned by every call when set
+}
+
+func (p *Portfolio) GetBalance(context.Context) (*kalshi.Balance, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	return &kalshi.Balance{Balance: p.Balance}, nil
+}
+
+func (p *Portfolio) GetPositions(context.Context, string) ([]kalshi.Position, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	return slices.Clone(p.Positions), nil
+}
+
+// GetFills honors the ticker parameter.
+func (p *Portfolio) GetFills(_ context.Context, params url.Values) ([]kalshi.Fill, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	return filterTicker(p.Fills, params, func(f kalshi.Fill) string { return f.Ticker }), nil
+}
+
+// GetSettlements honors the ticker parameter.
+func (p *Portfolio) GetSettlements(_ context.Context, params url.Values) ([]kalshi.Settlement, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	return filterTicker(p.Settlements, params, func(s kalshi.Settlement) string { return s.Ticker }), nil
+}
+
+func filterTicker[T any](items []T, params url.Values, ticker func(T) string) []T {
+	want := params.Get("ticker")
+	var out []T
+	for _, it := range items {
+		if want == "" || ticker(it) == want {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Journal is a fake strategy.EventSink that keeps events in memory.
+type Journal struct {
+	Events []any
+	Err    error // returned by Log, which then records nothing, when set
+}
+
+func (j *Journal) Log(event any) error {
+	if j.Err != nil {
+		return j.Err
+	}
+	j.Events = append(j.Events, event)
+	return nil
+}
+
+// Trades returns the journaled trades, oldest first.
+func (j *Journal) Trades() []journal.Trade {
+	return eventsOf[journal.Trade](j)
+}
+
+// Settlements returns the journaled settlements, oldest first.
+func (j *Journal) Settlements() []journal.Settlement {
+	return eventsOf[journal.Settlement](j)
+}
+
+func eventsOf[T any](j *Journal) []T {
+	var out []T
+	for _, ev := range j.Events {
+		if v, ok := ev.(T); ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func notFound(what string) error {
+	return &kalshi.APIError{StatusCode: http.StatusNotFound, Code: "not_found", Message: what + " not found"}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,6 +24,7 @@ type VolFilter struct {
 
 	// Data collector file reading
 	dataDir        string // path to data collector data directory
+	filePrefix     string // daily file prefix, e.g. "kxbtc15m" for kxbtc15m-2026-02-10.jsonl
 	lastRead       time.Time
 	lastFileOffset int64
 	lastFileName   string
@@ -38,13 +40,35 @@ type priceSample struct {
 // window: rolling window duration (e.g., 15 minutes)
 // maxStdDev: stddev threshold in dollars to block trading (e.g., 200.0)
 func NewVolFilter(dataDir string, window time.Duration, maxStdDev float64) *VolFilter {
+	return NewSeriesVolFilter(dataDir, "KXBTC15M", window, maxStdDev)
+}
+
+// NewSeriesVolFilter creates a volatility filter reading the data collector's
+// files for the given series (lowercased ticker is the file prefix).
+func NewSeriesVolFilter(dataDir, seriesTicker string, window time.Duration, maxStdDev float64) *VolFilter {
 	return &VolFilter{
-		dataDir:   dataDir,
-		window:    window,
-		maxStdDev: maxStdDev,
+		dataDir:    dataDir,
+		filePrefix: strings.ToLower(seriesTicker),
+		window:     window,
+		maxStdDev:  maxStdDev,
 	}
 }
 
+// SetWindow changes the rolling window; samples older than the new window
+// are dropped on the next Update.
+func (v *VolFilter) SetWindow(window time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.window = window
+}
+
+// Window returns the rolling window duration.
+func (v *VolFilter) Window() time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.window
+}
+
 // Update reads the latest BTC price from the data collector's JSONL file.
 // Call this periodically (e.g., every 10 seconds) from the engine tick.
 // Returns the latest BRTI price, or 0 if unavailable.
@@ -53,7 +77,7 @@ func (v *VolFilter) Update() float64 {
 	defer v.mu.Unlock()
 
 	now := time.Now().UTC()
-	fileName := fmt.Sprintf("%s/kxbtc15m-%s.jsonl", v.dataDir, now.Format("2006-01-02"))
+	fileName := fmt.Sprintf("%s/%s-%s.jsonl", v.dataDir, v.filePrefix, now.Format("2006-01-02"))
 
 	// Read new lines from the file
 	price, ts := v.readLatestPrice(fileName)
@@ -189,3 +213,38 @@ func (v *VolFilter) SampleCount() int {
 	defer v.mu.Unlock()
 	return len(v.samples)
 }
+
+// Price returns the most recent price sample in dollars, or 0 if none is
+// in the window.
+func (v *VolFilter) Price() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.samples) == 0 {
+		return 0
+	}
+	return v.samples[len(v.samples)-1].Price
+}
+
+// RealizedVol returns the realized volatility of log returns between
+// samples, per square root of second, or 0 with fewer than two distinct
+// samples.
+func (v *VolFilter) RealizedVol() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var sumSq, secs float64
+	for i := 1; i < len(v.samples); i++ {
+		prev, cur := v.samples[i-1], v.samples[i]
+		dt := cur.Time.Sub(prev.Time).Seconds()
+		if dt <= 0 || prev.Price <= 0 {
+			continue // repeated read of the same line
+		}
+		r := math.Log(cur.Price / prev.Price)
+		sumSq += r * r
+		secs += dt
+	}
+	if secs == 0 {
+		return 0
+	}
+	return math.Sqrt(sumSq / secs)
+}
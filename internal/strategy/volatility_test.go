@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"math"
 	"testing"
 	"time"
 )
@@ -127,3 +128,29 @@ func TestVolFilterTrimOldSamples(t *testing.T) {
 		t.Errorf("after trim, SampleCount() = %d, want 3", got)
 	}
 }
+
+func TestVolFilterRealizedVol(t *testing.T) {
+	vf := NewVolFilter("/nonexistent", 15*time.Minute, 200.0)
+	if got := vf.RealizedVol(); got != 0 {
+		t.Errorf("empty RealizedVol() = %g, want 0", got)
+	}
+
+	// Alternating ±1% moves every 10s, with a repeated read in between
+	now := time.Now()
+	vf.mu.Lock()
+	for i, p := range []float64{66000, 66660, 66660, 66000, 66660} {
+		ts := now.Add(time.Duration(i) * 10 * time.Second)
+		if i == 2 {
+			ts = now.Add(10 * time.Second)
+		}
+		vf.samples = append(vf.samples, priceSample{Price: p, Time: ts})
+	}
+	vf.mu.Unlock()
+
+	// Three moves of |ln(1.01)| over 40s
+	r := math.Log(66660.0 / 66000)
+	want := math.Sqrt(3 * r * r / 40)
+	if got := vf.RealizedVol(); math.Abs(got-want) > 1e-12 {
+		t.Errorf("RealizedVol() = %g, want %g", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package strategy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// WinRateKey identifies a bucket of trades expected to share a win rate.
+type WinRateKey struct {
+	Side   string // "yes" or "no"
+	Bucket int    // lowest entry price in the bucket, in cents
+	Regime string // "calm" or "volatile"; "" when regimes are off
+}
+
+// String returns the key as stored on disk, e.g. "yes/80" or "no/85/calm".
+func (k WinRateKey) String() string {
+	s := fmt.Sprintf("%s/%d", k.Side, k.Bucket)
+	if k.Regime != "" {
+		s += "/" + k.Regime
+	}
+	return s
+}
+
+// bucketRecord counts a bucket's settled trades.
+type bucketRecord struct {
+	Wins   int64 `json:"wins"`
+	Losses int64 `json:"losses"`
+}
+
+// WinRates keeps a win-rate posterior per entry-price bucket, side and,
+// optionally, vol regime. A bucket's posterior is Beta(1+wins, 1+losses);
+// until it has MinObs settled trades, the shared prior (BayesianWinRate,
+// updated nightly) is used instead.
+type WinRates struct {
+	prior    *BayesianPosterior
+	width    int     // bucket width in cents
+	minObs   int64   // observations before a bucket overrides the prior
+	volSplit float64 // stddev in dollars separating calm from volatile; 0 = no regimes
+
+	mu      sync.Mutex
+	buckets map[string]*bucketRecord // by WinRateKey.String()
+}
+
+// NewWinRates creates empty buckets of width cents over prior.
+func NewWinRates(prior *BayesianPosterior, width, minObs int, volSplit float64) *WinRates {
+	if width <= 0 {
+		width = 5
+	}
+	return &WinRates{
+		prior:    prior,
+		width:    width,
+		minObs:   int64(minObs),
+		volSplit: volSplit,
+		buckets:  make(map[string]*bucketRecord),
+	}
+}
+
+// Key returns the bucket for an entry of side at price, with the
+// underlying's stddev (dollars) at entry.
+func (w *WinRates) Key(side string, price int, volStdDev float64) WinRateKey {
+	k := WinRateKey{Side: side, Bucket: price - price%w.width}
+	if w.volSplit > 0 {
+		k.Regime = "calm"
+		if volStdDev >= w.volSplit {
+			k.Regime = "volatile"
+		}
+	}
+	return k
+}
+
+// WinRate returns the q-th quantile of the bucket's posterior, or of the
+// prior if the bucket has too few observations. fromBucket reports which.
+func (w *WinRates) WinRate(k WinRateKey, q float64) (p float64, fromBucket bool) {
+	w.mu.Lock()
+	rec := w.buckets[k.String()]
+	var post *BayesianPosterior
+	if rec != nil && rec.Wins+rec.Losses >= w.minObs {
+		post = &BayesianPosterior{Alpha: 1 + rec.Wins, Beta: 1 + rec.Losses}
+	}
+	w.mu.Unlock()
+
+	if post == nil {
+		return w.prior.Quantile(q), false
+	}
+	return post.Quantile(q), true
+}
+
+// Observe records a settled trade in its bucket.
+func (w *WinRates) Observe(k WinRateKey, won bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	rec := w.buckets[k.String()]
+	if rec == nil {
+		rec = &bucketRecord{}
+		w.buckets[k.String()] = rec
+	}
+	if won {
+		rec.Wins++
+	} else {
+		rec.Losses++
+	}
+}
+
+// Observations returns how many settled trades the bucket has.
+func (w *WinRates) Observations(k WinRateKey) int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if rec := w.buckets[k.String()]; rec != nil {
+		return rec.Wins + rec.Losses
+	}
+	return 0
+}
+
+// Total returns how many settled trades all buckets hold.
+func (w *WinRates) Total() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var n int64
+	for _, rec := range w.buckets {
+		n += rec.Wins + rec.Losses
+	}
+	return n
+}
+
+// LoadFromFile reads bucket counts from disk. A missing file leaves the
+// buckets empty.
+func (w *WinRates) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var stored struct {
+		Buckets map[string]*bucketRecord `json:"buckets"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buckets = make(map[string]*bucketRecord, len(stored.Buckets))
+	for k, rec := range stored.Buckets {
+		if rec != nil {
+			w.buckets[k] = rec
+		}
+	}
+	return nil
+}
+
+// SaveToFile writes bucket counts to disk.
+func (w *WinRates) SaveToFile(path string) error {
+	w.mu.Lock()
+	data, err := json.MarshalIndent(struct {
+		Buckets map[string]*bucketRecord `json:"buckets"`
+	}{w.buckets}, "", "  ")
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
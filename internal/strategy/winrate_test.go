@@ -0,0 +1,96 @@
+package strategy
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuantileMatchesPercentile5(t *testing.T) {
+	bp := &BayesianPosterior{Alpha: 83, Beta: 3}
+	if got, want := bp.Quantile(0.05), bp.Percentile5(); math.Abs(got-want) > 1e-4 {
+		t.Errorf("Quantile(0.05) = %.5f, want Percentile5 %.5f", got, want)
+	}
+	if got, want := bp.Quantile(0.5), bp.Mean(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Quantile(0.5) = %.5f, want mean %.5f", got, want)
+	}
+	if lo, hi := bp.Quantile(0.05), bp.Quantile(0.95); lo >= hi || hi > 1 {
+		t.Errorf("Quantile(0.05) = %v, Quantile(0.95) = %v", lo, hi)
+	}
+}
+
+func TestWinRatesKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		volSplit float64
+		side     string
+		price    int
+		stdDev   float64
+		want     string
+	}{
+		{"bucket floor", 0, "yes", 85, 10, "yes/85"},
+		{"inside bucket", 0, "yes", 89, 10, "yes/85"},
+		{"no side", 0, "no", 81, 10, "no/80"},
+		{"calm", 50, "yes", 82, 49.9, "yes/80/calm"},
+		{"volatile", 50, "no", 82, 50, "no/80/volatile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWinRates(NewBayesianPosterior(), 5, 30, tt.volSplit)
+			if got := w.Key(tt.side, tt.price, tt.stdDev).String(); got != tt.want {
+				t.Errorf("Key = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWinRatesMinObservations(t *testing.T) {
+	prior := NewBayesianPosterior()
+	w := NewWinRates(prior, 5, 10, 0)
+	key := WinRateKey{Side: "yes", Bucket: 80}
+
+	for range 9 {
+		w.Observe(key, false)
+	}
+	if p, fromBucket := w.WinRate(key, 0.05); fromBucket || p != prior.Quantile(0.05) {
+		t.Fatalf("WinRate with 9 observations = %v (bucket %v), want the prior", p, fromBucket)
+	}
+
+	w.Observe(key, false)
+	p, fromBucket := w.WinRate(key, 0.05)
+	if !fromBucket || p > 0.1 {
+		t.Errorf("WinRate with 10 losses = %v (bucket %v), want the bucket's low rate", p, fromBucket)
+	}
+	if p, fromBucket := w.WinRate(WinRateKey{Side: "no", Bucket: 80}, 0.05); fromBucket || p != prior.Quantile(0.05) {
+		t.Errorf("other side = %v (bucket %v), want the prior", p, fromBucket)
+	}
+}
+
+func TestWinRatesFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buckets.json")
+	w := NewWinRates(NewBayesianPosterior(), 5, 1, 0)
+	if err := w.LoadFromFile(path); err != nil {
+		t.Fatalf("loading a missing file: %v", err)
+	}
+
+	key := WinRateKey{Side: "no", Bucket: 90, Regime: "calm"}
+	w.Observe(key, true)
+	w.Observe(key, true)
+	w.Observe(key, false)
+	if err := w.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewWinRates(NewBayesianPosterior(), 5, 1, 0)
+	if err := loaded.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if n := loaded.Observations(key); n != 3 {
+		t.Errorf("loaded %d observations, want 3", n)
+	}
+	got, _ := loaded.WinRate(key, 0.5)
+	want, _ := w.WinRate(key, 0.5)
+	if got != want {
+		t.Errorf("loaded WinRate = %v, want %v", got, want)
+	}
+}